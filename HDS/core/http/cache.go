@@ -0,0 +1,622 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is the private HTTP cache of RFC 9111 every request goes through.
+// Entries live on disk, the most recently used bodies are kept in memory
+// as well.
+var Cache = NewCache(filepath.Join(ProfileDir(), "cache"), 128<<20, 16<<20)
+
+// heuristicallyCacheable are the statuses that may be cached without
+// explicit freshness, RFC 9110 section 15.1.
+var heuristicallyCacheable = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+type cacheEntry struct {
+	ID           string            `json:"id"`
+	URL          string            `json:"url"`
+	Vary         map[string]string `json:"vary,omitempty"`
+	Status       int               `json:"status"`
+	Header       http.Header       `json:"header"`
+	RequestTime  time.Time         `json:"request_time"`
+	ResponseTime time.Time         `json:"response_time"`
+	Size         int64             `json:"size"`
+
+	body    []byte
+	element *list.Element
+}
+
+type HTTPCache struct {
+	mu        sync.Mutex
+	dir       string
+	maxDisk   int64
+	maxMemory int64
+
+	loaded   bool
+	byURL    map[string][]*cacheEntry
+	lru      *list.List
+	diskSize int64
+	memSize  int64
+}
+
+// NewCache keeps up to maxDisk bytes of responses in dir, maxMemory of
+// them in memory too. An empty dir keeps everything in memory.
+func NewCache(dir string, maxDisk, maxMemory int64) *HTTPCache {
+	return &HTTPCache{
+		dir:       dir,
+		maxDisk:   maxDisk,
+		maxMemory: maxMemory,
+		byURL:     make(map[string][]*cacheEntry),
+		lru:       list.New(),
+	}
+}
+
+type revalidateKey struct{}
+
+// Revalidate makes the requests done with ctx check cached responses with
+// the server even when they are still fresh, as a reload does.
+func Revalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+// Clear drops every cached response.
+func (c *HTTPCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		c.remove(e.Value.(*cacheEntry))
+		e = next
+	}
+}
+
+// cacheTransport answers requests from the cache while they are fresh,
+// revalidates stale entries and stores what the server sends.
+type cacheTransport struct {
+	base  http.RoundTripper
+	cache *HTTPCache
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && resp.StatusCode < 400 {
+			t.cache.invalidate(req.URL.String())
+		}
+		return resp, err
+	}
+
+	requestCC := parseCacheControl(req.Header.Values("Cache-Control"))
+	if _, ok := requestCC["no-store"]; ok || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	force, _ := req.Context().Value(revalidateKey{}).(bool)
+	if _, ok := requestCC["no-cache"]; ok || strings.Contains(req.Header.Get("Pragma"), "no-cache") {
+		force = true
+	}
+	if maxAge, ok := requestCC["max-age"]; ok && maxAge == "0" {
+		force = true
+	}
+
+	conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+	entry, body := t.cache.lookup(req)
+	if entry != nil && !conditional {
+		if !force && entry.fresh(time.Now()) {
+			return entry.response(req, body), nil
+		}
+
+		if etag, lastModified := entry.Header.Get("ETag"), entry.Header.Get("Last-Modified"); etag != "" || lastModified != "" {
+			req = req.Clone(req.Context())
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				req.Header.Set("If-Modified-Since", lastModified)
+			}
+		} else {
+			entry = nil
+		}
+	}
+
+	requestTime := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now()
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil && !conditional {
+		resp.Body.Close()
+		if refreshed, body := t.cache.refresh(entry, resp.Header, requestTime, responseTime); body != nil {
+			// the cookies a 304 sets are not stored but still reach the jar
+			cached := refreshed.response(req, body)
+			for _, name := range []string{"Set-Cookie", "Set-Cookie2"} {
+				if values := resp.Header.Values(name); len(values) > 0 {
+					cached.Header[name] = values
+				}
+			}
+			return cached, nil
+		}
+		// the body went missing from disk, fetch it in full
+		req = req.Clone(req.Context())
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+		return t.RoundTrip(req)
+	}
+
+	if req.Method == "GET" && storable(resp) {
+		stored := &cacheEntry{
+			URL:          req.URL.String(),
+			Vary:         varyValues(req, resp.Header),
+			Status:       resp.StatusCode,
+			Header:       storedHeader(resp.Header),
+			RequestTime:  requestTime,
+			ResponseTime: responseTime,
+		}
+		resp.Body = &cachingBody{ReadCloser: resp.Body, limit: t.cache.maxDisk / 8, done: func(body []byte) {
+			t.cache.store(stored, body)
+		}}
+	}
+
+	return resp, nil
+}
+
+// cachingBody copies the body as the caller reads it and stores it once
+// it was read to the end.
+type cachingBody struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	limit int64
+	done  func(body []byte)
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.done != nil {
+		b.buf.Write(p[:n])
+		if int64(b.buf.Len()) > b.limit {
+			b.done = nil
+			b.buf = bytes.Buffer{}
+		} else if err == io.EOF {
+			b.done(b.buf.Bytes())
+			b.done = nil
+		}
+	}
+	return n, err
+}
+
+func storable(resp *http.Response) bool {
+	cc := parseCacheControl(resp.Header.Values("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	// varying on "*" can never match, on credentials it would write them
+	// to disk
+	for _, name := range varyNames(resp.Header) {
+		if name == "*" || privateHeaders[name] {
+			return false
+		}
+	}
+
+	// without freshness or validators an entry could never be used
+	_, maxAge := cc["max-age"]
+	explicit := maxAge || resp.Header.Get("Expires") != ""
+	if heuristicallyCacheable[resp.StatusCode] {
+		return explicit || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+	}
+	return explicit && resp.StatusCode < 500 && resp.StatusCode != http.StatusPartialContent
+}
+
+// storedHeader drops what must not be replayed from the cache.
+func storedHeader(header http.Header) http.Header {
+	stored := header.Clone()
+	stored.Del("Set-Cookie")
+	stored.Del("Set-Cookie2")
+	return stored
+}
+
+// privateHeaders are request headers whose values must not be stored.
+var privateHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+}
+
+// varyNames lists the request headers a response varies on.
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, line := range header.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// varyValues records the request headers the response varies on.
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	values := make(map[string]string)
+	for _, name := range varyNames(header) {
+		values[name] = strings.Join(req.Header.Values(name), ", ")
+	}
+	return values
+}
+
+func (e *cacheEntry) matches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if strings.Join(req.Header.Values(name), ", ") != value {
+			return false
+		}
+	}
+	return true
+}
+
+// fresh is RFC 9111 section 4.2.
+func (e *cacheEntry) fresh(now time.Time) bool {
+	cc := parseCacheControl(e.Header.Values("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	return e.lifetime(cc) > e.age(now)
+}
+
+func (e *cacheEntry) lifetime(cc map[string]string) time.Duration {
+	if maxAge, ok := cc["max-age"]; ok {
+		seconds, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+
+	// heuristic freshness, a tenth of the time since the last change
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && heuristicallyCacheable[e.Status] {
+		return min(date.Sub(lastModified)/10, 24*time.Hour)
+	}
+	return 0
+}
+
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparent := time.Duration(0)
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		apparent = max(0, e.ResponseTime.Sub(date))
+	}
+
+	corrected := e.ResponseTime.Sub(e.RequestTime)
+	if seconds, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil {
+		corrected += time.Duration(seconds) * time.Second
+	}
+
+	return max(apparent, corrected) + now.Sub(e.ResponseTime)
+}
+
+func (e *cacheEntry) response(req *http.Request, body []byte) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.age(time.Now())/time.Second), 10))
+
+	if req.Method == "HEAD" {
+		body = nil
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func parseCacheControl(lines []string) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range lines {
+		for _, part := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				directives[name] = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// lookup finds the entry stored for req and its body. The entry is a copy
+// the caller may read without holding the lock.
+func (c *HTTPCache) lookup(req *http.Request) (*cacheEntry, []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	for _, entry := range c.byURL[req.URL.String()] {
+		if !entry.matches(req) {
+			continue
+		}
+
+		body := c.body(entry)
+		if body == nil {
+			c.remove(entry)
+			return nil, nil
+		}
+		c.lru.MoveToFront(entry.element)
+		return entry.snapshot(), body
+	}
+	return nil, nil
+}
+
+func (e *cacheEntry) snapshot() *cacheEntry {
+	snapshot := *e
+	snapshot.Header = e.Header.Clone()
+	snapshot.body, snapshot.element = nil, nil
+	return &snapshot
+}
+
+// stored is the entry in the cache entry is a copy of, nil when it was
+// removed since.
+func (c *HTTPCache) stored(entry *cacheEntry) *cacheEntry {
+	for _, stored := range c.byURL[entry.URL] {
+		if stored.ID == entry.ID {
+			return stored
+		}
+	}
+	return nil
+}
+
+// refresh updates the entry looked up as entry with the headers of a 304,
+// it returns a copy of the updated entry and its body. The header is
+// replaced rather than edited, copies taken before may still be in use.
+func (c *HTTPCache) refresh(entry *cacheEntry, header http.Header, requestTime, responseTime time.Time) (*cacheEntry, []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored := c.stored(entry)
+	if stored == nil {
+		return nil, nil
+	}
+	body := c.body(stored)
+	if body == nil {
+		c.remove(stored)
+		return nil, nil
+	}
+
+	updated := stored.Header.Clone()
+	for name, values := range storedHeader(header) {
+		switch name {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue
+		}
+		updated[name] = values
+	}
+	stored.Header = updated
+	stored.RequestTime, stored.ResponseTime = requestTime, responseTime
+	c.lru.MoveToFront(stored.element)
+	c.writeMeta(stored)
+
+	return stored.snapshot(), body
+}
+
+func (c *HTTPCache) store(entry *cacheEntry, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	entry.ID = entryID(entry)
+	entry.Size = int64(len(body))
+	entry.body = append([]byte(nil), body...)
+
+	for _, old := range c.byURL[entry.URL] {
+		if old.ID == entry.ID {
+			c.remove(old)
+			break
+		}
+	}
+
+	if c.dir != "" {
+		if err := writeFileAtomic(c.path(entry.ID, ".body"), entry.body); err != nil {
+			log.Printf("Cache ? %v", err)
+			return
+		}
+		c.writeMeta(entry)
+	}
+
+	c.insert(entry)
+	c.memSize += entry.Size
+	c.evict()
+}
+
+func (c *HTTPCache) invalidate(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	for _, entry := range c.byURL[url] {
+		c.remove(entry)
+	}
+}
+
+func (c *HTTPCache) insert(entry *cacheEntry) {
+	entry.element = c.lru.PushFront(entry)
+	c.byURL[entry.URL] = append(c.byURL[entry.URL], entry)
+	c.diskSize += entry.Size
+}
+
+func (c *HTTPCache) remove(entry *cacheEntry) {
+	if entry.element == nil {
+		return
+	}
+	c.lru.Remove(entry.element)
+	entry.element = nil
+
+	variants := c.byURL[entry.URL]
+	for i, other := range variants {
+		if other == entry {
+			variants = append(variants[:i], variants[i+1:]...)
+			break
+		}
+	}
+	if len(variants) == 0 {
+		delete(c.byURL, entry.URL)
+	} else {
+		c.byURL[entry.URL] = variants
+	}
+
+	c.diskSize -= entry.Size
+	if entry.body != nil {
+		c.memSize -= entry.Size
+		entry.body = nil
+	}
+
+	if c.dir != "" {
+		os.Remove(c.path(entry.ID, ".meta"))
+		os.Remove(c.path(entry.ID, ".body"))
+	}
+}
+
+// evict drops the least recently used entries past the disk limit, and
+// the bodies past the memory limit.
+func (c *HTTPCache) evict() {
+	for c.diskSize > c.maxDisk && c.lru.Len() > 0 {
+		c.remove(c.lru.Back().Value.(*cacheEntry))
+	}
+
+	if c.dir == "" {
+		return
+	}
+	for e := c.lru.Back(); e != nil && c.memSize > c.maxMemory; e = e.Prev() {
+		if entry := e.Value.(*cacheEntry); entry.body != nil {
+			c.memSize -= entry.Size
+			entry.body = nil
+		}
+	}
+}
+
+func (c *HTTPCache) body(entry *cacheEntry) []byte {
+	if entry.body != nil || c.dir == "" {
+		return entry.body
+	}
+
+	body, err := os.ReadFile(c.path(entry.ID, ".body"))
+	if err != nil || int64(len(body)) != entry.Size {
+		return nil
+	}
+
+	entry.body = body
+	c.memSize += entry.Size
+	c.evict()
+	return body
+}
+
+// load reads the index of the entries a previous run left on disk, the
+// bodies are read when they are used.
+func (c *HTTPCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+
+	if c.dir == "" {
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.meta"))
+	if err != nil {
+		return
+	}
+
+	var entries []*cacheEntry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		entry := &cacheEntry{}
+		if err := json.Unmarshal(data, entry); err != nil || entry.ID == "" {
+			os.Remove(file)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// the oldest response is the least recently used one we can tell
+	sort.Slice(entries, func(a, b int) bool { return entries[a].ResponseTime.After(entries[b].ResponseTime) })
+	for _, entry := range entries {
+		entry.element = c.lru.PushBack(entry)
+		c.byURL[entry.URL] = append(c.byURL[entry.URL], entry)
+		c.diskSize += entry.Size
+	}
+	c.evict()
+}
+
+func (c *HTTPCache) writeMeta(entry *cacheEntry) {
+	if c.dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = writeFileAtomic(c.path(entry.ID, ".meta"), data)
+	}
+	if err != nil {
+		log.Printf("Cache ? %v", err)
+	}
+}
+
+func (c *HTTPCache) path(id, ext string) string {
+	return filepath.Join(c.dir, id+ext)
+}
+
+func entryID(entry *cacheEntry) string {
+	names := make([]string, 0, len(entry.Vary))
+	for name := range entry.Vary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := entry.URL
+	for _, name := range names {
+		key += "\n" + name + ": " + entry.Vary[name]
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,292 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheLifetime(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	date := now.Format(http.TimeFormat)
+	at := func(d time.Duration) string { return now.Add(d).Format(http.TimeFormat) }
+
+	tests := []struct {
+		name   string
+		status int
+		header map[string]string
+		want   time.Duration
+	}{
+		{"max-age", 200, map[string]string{"Cache-Control": "max-age=60"}, time.Minute},
+		{"max-age over expires", 200, map[string]string{"Cache-Control": "max-age=60", "Expires": at(time.Hour), "Date": date}, time.Minute},
+		{"bad max-age", 200, map[string]string{"Cache-Control": "max-age=soon"}, 0},
+		{"expires", 200, map[string]string{"Expires": at(time.Hour), "Date": date}, time.Hour},
+		{"expires in the past", 200, map[string]string{"Expires": at(-time.Hour), "Date": date}, -time.Hour},
+		{"bad expires", 200, map[string]string{"Expires": "0", "Date": date}, 0},
+		{"heuristic", 200, map[string]string{"Last-Modified": at(-10 * time.Hour), "Date": date}, time.Hour},
+		{"heuristic cap", 200, map[string]string{"Last-Modified": at(-1000 * time.Hour), "Date": date}, 24 * time.Hour},
+		{"heuristic status", 404, map[string]string{"Last-Modified": at(-10 * time.Hour), "Date": date}, time.Hour},
+		{"no heuristic status", 302, map[string]string{"Last-Modified": at(-10 * time.Hour), "Date": date}, 0},
+		{"nothing", 200, map[string]string{"Date": date}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := &cacheEntry{Status: test.status, Header: make(http.Header), ResponseTime: now}
+			for name, value := range test.header {
+				entry.Header.Set(name, value)
+			}
+			if got := entry.lifetime(parseCacheControl(entry.Header.Values("Cache-Control"))); got != test.want {
+				t.Errorf("lifetime = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCacheFresh(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		header   map[string]string
+		received time.Duration
+		want     bool
+	}{
+		{"young", map[string]string{"Cache-Control": "max-age=60"}, -30 * time.Second, true},
+		{"old", map[string]string{"Cache-Control": "max-age=60"}, -90 * time.Second, false},
+		{"age header", map[string]string{"Cache-Control": "max-age=60", "Age": "50"}, -20 * time.Second, false},
+		{"late date", map[string]string{"Cache-Control": "max-age=60", "Date": now.Add(-2 * time.Minute).Format(http.TimeFormat)}, -10 * time.Second, false},
+		{"no-cache", map[string]string{"Cache-Control": "no-cache, max-age=60"}, -time.Second, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			received := now.Add(test.received)
+			entry := &cacheEntry{Status: 200, Header: make(http.Header), RequestTime: received, ResponseTime: received}
+			for name, value := range test.header {
+				entry.Header.Set(name, value)
+			}
+			if got := entry.fresh(now); got != test.want {
+				t.Errorf("fresh = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCacheStorable(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header map[string]string
+		want   bool
+	}{
+		{"max-age", 200, map[string]string{"Cache-Control": "max-age=60"}, true},
+		{"validator only", 200, map[string]string{"ETag": `"v1"`}, true},
+		{"nothing", 200, nil, false},
+		{"no-store", 200, map[string]string{"Cache-Control": "no-store, max-age=60"}, false},
+		{"vary star", 200, map[string]string{"Cache-Control": "max-age=60", "Vary": "*"}, false},
+		{"vary encoding", 200, map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Encoding"}, true},
+		{"vary cookie", 200, map[string]string{"Cache-Control": "max-age=60", "Vary": "Accept-Encoding, cookie"}, false},
+		{"vary authorization", 200, map[string]string{"Cache-Control": "max-age=60", "Vary": "Authorization"}, false},
+		{"redirect with max-age", 302, map[string]string{"Cache-Control": "max-age=60"}, true},
+		{"redirect with validator", 302, map[string]string{"ETag": `"v1"`}, false},
+		{"server error", 500, map[string]string{"Cache-Control": "max-age=60"}, false},
+		{"partial", 206, map[string]string{"Cache-Control": "max-age=60"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: test.status, Header: make(http.Header)}
+			for name, value := range test.header {
+				resp.Header.Set(name, value)
+			}
+			if got := storable(resp); got != test.want {
+				t.Errorf("storable = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// cachedClient is a client going through a fresh jar and a cache in a
+// temporary directory.
+func cachedClient(t *testing.T) (*http.Client, *Jar, *HTTPCache) {
+	dir := t.TempDir()
+	jar := NewJar(filepath.Join(dir, "cookies.json"))
+	cache := NewCache(filepath.Join(dir, "cache"), 1<<20, 1<<20)
+	return &http.Client{Transport: &cookieTransport{jar: jar, base: &cacheTransport{cache: cache, base: http.DefaultTransport}}}, jar, cache
+}
+
+func get(t *testing.T, client *http.Client, rawURL string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestCacheRevalidate(t *testing.T) {
+	var requests, conditional atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.Header().Set("X-Version", "2")
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "renewed"})
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("X-Version", "1")
+		w.Header().Set("Content-Type", "text/plain")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "first"})
+		io.WriteString(w, "cached body")
+	}))
+	defer srv.Close()
+
+	client, jar, cache := cachedClient(t)
+	if _, body := get(t, client, srv.URL); body != "cached body" {
+		t.Fatalf("first body = %q", body)
+	}
+
+	resp, body := get(t, client, srv.URL)
+	if body != "cached body" {
+		t.Errorf("revalidated body = %q, want the cached one", body)
+	}
+	if requests.Load() != 2 || conditional.Load() != 1 {
+		t.Errorf("server saw %d requests, %d conditional, want 2 and 1", requests.Load(), conditional.Load())
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-Version"); got != "2" {
+		t.Errorf("X-Version = %q, want the 304's 2", got)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/plain" {
+		t.Errorf("Content-Type = %q, want the stored text/plain", got)
+	}
+	if got := cookieHeader(t, jar, srv.URL); got != "session=renewed" {
+		t.Errorf("Cookie = %q, want the one the 304 set", got)
+	}
+
+	// the stored entry took the new headers but not the cookie
+	entry, _ := cache.lookup(resp.Request)
+	if entry == nil {
+		t.Fatal("entry went missing")
+	}
+	if got := entry.Header.Get("X-Version"); got != "2" {
+		t.Errorf("stored X-Version = %q, want 2", got)
+	}
+	if got := entry.Header.Values("Set-Cookie"); len(got) > 0 {
+		t.Errorf("stored Set-Cookie = %q, want none", got)
+	}
+}
+
+func TestCacheFreshHit(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		io.WriteString(w, "fresh")
+	}))
+	defer srv.Close()
+
+	client, _, _ := cachedClient(t)
+	get(t, client, srv.URL)
+	resp, body := get(t, client, srv.URL)
+	if body != "fresh" || requests.Load() != 1 {
+		t.Errorf("body %q after %d requests, want the cached one after 1", body, requests.Load())
+	}
+	if resp.Header.Get("Age") == "" {
+		t.Error("cached response has no Age")
+	}
+
+	if _, err := client.Post(srv.URL, "text/plain", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	get(t, client, srv.URL)
+	if requests.Load() != 3 {
+		t.Errorf("server saw %d requests, want a POST to invalidate the entry", requests.Load())
+	}
+}
+
+func TestCacheVaryCookie(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Header().Set("Vary", "Cookie")
+		io.WriteString(w, "private")
+	}))
+	defer srv.Close()
+
+	client, jar, cache := cachedClient(t)
+	setCookie(t, jar, srv.URL, "secret=hunter2")
+	resp, _ := get(t, client, srv.URL)
+
+	if entry, _ := cache.lookup(resp.Request); entry != nil {
+		t.Error("a response varying on Cookie was stored")
+	}
+	filepath.WalkDir(cache.dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if data, _ := os.ReadFile(path); strings.Contains(string(data), "hunter2") {
+				t.Errorf("%s holds the cookie", path)
+			}
+		}
+		return nil
+	})
+}
+
+// TestCacheConcurrentRefresh is meant for -race, loads of one URL read
+// the entry they looked up while others refresh it.
+func TestCacheConcurrentRefresh(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"), 1<<20, 1<<20)
+	now := time.Now()
+	cache.store(&cacheEntry{
+		URL:          "http://example.com/style.css",
+		Status:       200,
+		Header:       http.Header{"Cache-Control": {"max-age=0"}, "Etag": {`"v1"`}},
+		RequestTime:  now,
+		ResponseTime: now,
+	}, []byte("body"))
+
+	req, err := http.NewRequest("GET", "http://example.com/style.css", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				entry, body := cache.lookup(req)
+				if entry == nil || string(body) != "body" {
+					t.Error("entry went missing")
+					return
+				}
+				entry.fresh(time.Now())
+				entry.response(req, body).Body.Close()
+
+				header := http.Header{"X-Refresh": {strconv.Itoa(i*50 + j)}}
+				if refreshed, _ := cache.refresh(entry, header, time.Now(), time.Now()); refreshed != nil {
+					refreshed.Header.Get("X-Refresh")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
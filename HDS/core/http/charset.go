@@ -0,0 +1,72 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// regionalCharsets are the legacy encodings assumed for undeclared pages by
+// top-level domain, as browsers do, instead of windows-1252.
+var regionalCharsets = map[string]string{
+	"ru": "windows-1251", "su": "windows-1251", "xn--p1ai": "windows-1251",
+	"ua": "windows-1251", "by": "windows-1251", "kz": "windows-1251",
+	"bg": "windows-1251", "mk": "windows-1251", "rs": "windows-1251",
+	"pl": "iso-8859-2", "cz": "windows-1250", "sk": "windows-1250", "hu": "iso-8859-2",
+	"gr": "iso-8859-7", "tr": "windows-1254", "il": "windows-1255",
+	"jp": "shift_jis", "cn": "gbk", "tw": "big5", "kr": "euc-kr",
+}
+
+// decodeText transcodes a text body to UTF-8. The charset comes from a BOM,
+// the Content-Type header or a <meta> declaration, in that order, binary
+// bodies are returned as they are.
+func decodeText(body []byte, contentType, address string) (string, string) {
+	if !isText(body, contentType) {
+		return string(body), ""
+	}
+
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	if !certain && name == "windows-1252" {
+		if regional, ok := regionalCharsets[topLevelDomain(address)]; ok {
+			if e, n := charset.Lookup(regional); e != nil {
+				enc, name = e, n
+			}
+		}
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return string(body), ""
+	}
+	return strings.TrimPrefix(string(decoded), "\ufeff"), name
+}
+
+func isText(body []byte, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/xml",
+		mediaType == "application/json",
+		mediaType == "application/javascript":
+		return true
+	}
+	return false
+}
+
+func topLevelDomain(address string) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return ""
+	}
+
+	host := canonicalHost(u.Host)
+	return host[strings.LastIndex(host, ".")+1:]
+}
@@ -0,0 +1,472 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	maxCookiesPerDomain = 180
+	maxCookies          = 3000
+)
+
+// Cookie is a stored cookie, RFC 6265 section 5.3. Expires is zero for
+// session cookies, those are never written to disk.
+type Cookie struct {
+	Name       string    `json:"name"`
+	Value      string    `json:"value"`
+	Domain     string    `json:"domain"`
+	Path       string    `json:"path"`
+	HostOnly   bool      `json:"host_only"`
+	Secure     bool      `json:"secure"`
+	HttpOnly   bool      `json:"http_only"`
+	SameSite   string    `json:"same_site,omitempty"`
+	Expires    time.Time `json:"expires"`
+	Created    time.Time `json:"created"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+func (c *Cookie) Persistent() bool {
+	return !c.Expires.IsZero()
+}
+
+func (c *Cookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+// Jar keeps the cookies of every site, persistent ones in a JSON file.
+type Jar struct {
+	mu      sync.Mutex
+	file    string
+	loaded  bool
+	cookies map[string]*Cookie
+}
+
+// Cookies is the jar every request goes through.
+var Cookies = NewJar(filepath.Join(ProfileDir(), "cookies.json"))
+
+func NewJar(file string) *Jar {
+	return &Jar{file: file, cookies: make(map[string]*Cookie)}
+}
+
+// SetCookies stores the cookies a response from u set.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+
+	host := canonicalHost(u.Host)
+	secure := u.Scheme == "https"
+	now := time.Now()
+	changed := false
+
+	for _, hc := range cookies {
+		c := &Cookie{
+			Name:       hc.Name,
+			Value:      hc.Value,
+			Path:       hc.Path,
+			Secure:     hc.Secure,
+			HttpOnly:   hc.HttpOnly,
+			SameSite:   sameSiteName(hc.SameSite),
+			Created:    now,
+			LastAccess: now,
+		}
+
+		domain := strings.TrimPrefix(strings.ToLower(hc.Domain), ".")
+		switch {
+		case domain == "":
+			c.Domain, c.HostOnly = host, true
+		case isPublicSuffix(domain):
+			if domain != host {
+				continue
+			}
+			c.Domain, c.HostOnly = host, true
+		case !domainMatch(host, domain):
+			continue
+		default:
+			c.Domain = domain
+		}
+
+		if c.Path == "" || c.Path[0] != '/' {
+			c.Path = defaultPath(u.Path)
+		}
+
+		// a plain http page may not set or overwrite a Secure cookie
+		if !secure {
+			if c.Secure {
+				continue
+			}
+			if old, ok := j.cookies[c.key()]; ok && old.Secure {
+				continue
+			}
+		}
+
+		expired := false
+		switch {
+		case hc.MaxAge < 0:
+			expired = true
+		case hc.MaxAge > 0:
+			c.Expires = now.Add(time.Duration(hc.MaxAge) * time.Second)
+		case !hc.Expires.IsZero():
+			c.Expires = hc.Expires
+			expired = !hc.Expires.After(now)
+		}
+
+		if old, ok := j.cookies[c.key()]; ok {
+			c.Created = old.Created
+			changed = changed || old.Persistent() || c.Persistent()
+			delete(j.cookies, c.key())
+		}
+		if !expired {
+			j.cookies[c.key()] = c
+			changed = changed || c.Persistent()
+		}
+	}
+
+	if j.evict(now) || changed {
+		j.save()
+	}
+}
+
+// Cookies returns the cookies to send to u, longest paths first.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.cookiesFor(u, nil, false)
+}
+
+// cookiesFor leaves out SameSite cookies on requests made from a page of
+// another site, Lax ones are kept for a safe top-level navigation.
+// firstParty is nil for what the user asked for.
+func (j *Jar) cookiesFor(u *url.URL, firstParty *url.URL, safeNavigation bool) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+
+	host := canonicalHost(u.Host)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	crossSite := firstParty != nil && !sameSite(firstParty, host)
+	now := time.Now()
+
+	var matched []*Cookie
+	for key, c := range j.cookies {
+		if c.Persistent() && !c.Expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+
+		if c.HostOnly && host != c.Domain || !c.HostOnly && !domainMatch(host, c.Domain) {
+			continue
+		}
+		if !pathMatch(path, c.Path) || c.Secure && u.Scheme != "https" {
+			continue
+		}
+		if crossSite && (c.SameSite == "strict" || c.SameSite == "lax" && !safeNavigation) {
+			continue
+		}
+
+		c.LastAccess = now
+		matched = append(matched, c)
+	}
+
+	sort.Slice(matched, func(a, b int) bool {
+		if len(matched[a].Path) != len(matched[b].Path) {
+			return len(matched[a].Path) > len(matched[b].Path)
+		}
+		return matched[a].Created.Before(matched[b].Created)
+	})
+
+	cookies := make([]*http.Cookie, len(matched))
+	for i, c := range matched {
+		cookies[i] = &http.Cookie{Name: c.Name, Value: c.Value}
+	}
+	return cookies
+}
+
+// Sites lists the domains that have cookies stored.
+func (j *Jar) Sites() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+
+	seen := make(map[string]bool)
+	var sites []string
+	for _, c := range j.cookies {
+		if site := registrableDomain(c.Domain); !seen[site] {
+			seen[site] = true
+			sites = append(sites, site)
+		}
+	}
+	sort.Strings(sites)
+	return sites
+}
+
+// SiteCookies returns copies of the cookies stored for site and its
+// subdomains.
+func (j *Jar) SiteCookies(site string) []Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+
+	var cookies []Cookie
+	for _, c := range j.cookies {
+		if registrableDomain(c.Domain) == site {
+			cookies = append(cookies, *c)
+		}
+	}
+	sort.Slice(cookies, func(a, b int) bool {
+		if cookies[a].Domain != cookies[b].Domain {
+			return cookies[a].Domain < cookies[b].Domain
+		}
+		return cookies[a].Name < cookies[b].Name
+	})
+	return cookies
+}
+
+// Clear removes the cookies of site, or every cookie when site is empty.
+func (j *Jar) Clear(site string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+
+	for key, c := range j.cookies {
+		if site == "" || registrableDomain(c.Domain) == site {
+			delete(j.cookies, key)
+		}
+	}
+	j.save()
+}
+
+// evict drops expired cookies and the least recently used ones past the
+// limits, it reports whether anything was removed.
+func (j *Jar) evict(now time.Time) bool {
+	removed := false
+	perDomain := make(map[string][]*Cookie)
+
+	for key, c := range j.cookies {
+		if c.Persistent() && !c.Expires.After(now) {
+			delete(j.cookies, key)
+			removed = true
+			continue
+		}
+		perDomain[c.Domain] = append(perDomain[c.Domain], c)
+	}
+
+	byAccess := func(list []*Cookie) {
+		sort.Slice(list, func(a, b int) bool { return list[a].LastAccess.Before(list[b].LastAccess) })
+	}
+
+	for _, list := range perDomain {
+		if len(list) <= maxCookiesPerDomain {
+			continue
+		}
+		byAccess(list)
+		for _, c := range list[:len(list)-maxCookiesPerDomain] {
+			delete(j.cookies, c.key())
+		}
+		removed = true
+	}
+
+	if len(j.cookies) > maxCookies {
+		all := make([]*Cookie, 0, len(j.cookies))
+		for _, c := range j.cookies {
+			all = append(all, c)
+		}
+		byAccess(all)
+		for _, c := range all[:len(all)-maxCookies] {
+			delete(j.cookies, c.key())
+		}
+		removed = true
+	}
+
+	return removed
+}
+
+func (j *Jar) load() {
+	if j.loaded {
+		return
+	}
+	j.loaded = true
+
+	data, err := os.ReadFile(j.file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Cookies ? %v", err)
+		}
+		return
+	}
+
+	var cookies []*Cookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		log.Printf("Cookies ? %s: %v", j.file, err)
+		return
+	}
+
+	now := time.Now()
+	for _, c := range cookies {
+		if c.Persistent() && c.Expires.After(now) {
+			j.cookies[c.key()] = c
+		}
+	}
+}
+
+func (j *Jar) save() {
+	cookies := make([]*Cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if c.Persistent() {
+			cookies = append(cookies, c)
+		}
+	}
+
+	data, err := json.MarshalIndent(cookies, "", "\t")
+	if err == nil {
+		err = writeFileAtomic(j.file, data)
+	}
+	if err != nil {
+		log.Printf("Cookies ? %v", err)
+	}
+}
+
+type firstPartyKey struct{}
+
+type navigationKey struct{}
+
+// WithFirstParty marks the requests made with ctx as subresources of the
+// page at pageURL, SameSite cookies are only sent if it is the same site.
+func WithFirstParty(ctx context.Context, pageURL string) context.Context {
+	ctx = context.WithValue(ctx, navigationKey{}, false)
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Scheme == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, firstPartyKey{}, u)
+}
+
+// WithNavigation marks the requests made with ctx as a top-level
+// navigation started from the page at initiator, an empty initiator is the
+// user. Lax cookies still go along with cross-site GET navigations.
+func WithNavigation(ctx context.Context, initiator string) context.Context {
+	if initiator == "" {
+		return ctx
+	}
+	return context.WithValue(WithFirstParty(ctx, initiator), navigationKey{}, true)
+}
+
+// cookieTransport adds the jar's cookies to every request and stores the
+// ones responses set, redirects included.
+type cookieTransport struct {
+	base http.RoundTripper
+	jar  *Jar
+}
+
+func (t *cookieTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	firstParty, _ := req.Context().Value(firstPartyKey{}).(*url.URL)
+	navigation, _ := req.Context().Value(navigationKey{}).(bool)
+	safe := navigation && (req.Method == "GET" || req.Method == "HEAD")
+
+	if cookies := t.jar.cookiesFor(req.URL, firstParty, safe); len(cookies) > 0 {
+		req = req.Clone(req.Context())
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.jar.SetCookies(req.URL, resp.Cookies())
+	return resp, nil
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteStrictMode:
+		return "strict"
+	case http.SameSiteLaxMode:
+		return "lax"
+	case http.SameSiteNoneMode:
+		return "none"
+	}
+	return ""
+}
+
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// domainMatch is RFC 6265 section 5.1.3.
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return strings.HasSuffix(host, "."+domain) && net.ParseIP(host) == nil
+}
+
+// pathMatch is RFC 6265 section 5.1.4.
+func pathMatch(path, cookiePath string) bool {
+	if path == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	if i := strings.LastIndex(path, "/"); i > 0 {
+		return path[:i]
+	}
+	return "/"
+}
+
+func isPublicSuffix(domain string) bool {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix == domain
+}
+
+// sameSite tells whether page is on the site of host, pages that are not
+// from the web are on none.
+func sameSite(page *url.URL, host string) bool {
+	if page.Scheme != "http" && page.Scheme != "https" {
+		return false
+	}
+	return registrableDomain(canonicalHost(page.Host)) == registrableDomain(host)
+}
+
+// registrableDomain is the site a host belongs to, example.com for
+// www.example.com.
+func registrableDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
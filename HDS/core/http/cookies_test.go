@@ -0,0 +1,276 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func setCookie(t *testing.T, jar *Jar, rawURL string, lines ...string) {
+	t.Helper()
+	var cookies []*http.Cookie
+	for _, line := range lines {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		cookies = append(cookies, c)
+	}
+	jar.SetCookies(mustParse(t, rawURL), cookies)
+}
+
+// cookieHeader is what the jar sends to rawURL, as in a Cookie header.
+func cookieHeader(t *testing.T, jar *Jar, rawURL string) string {
+	t.Helper()
+	var pairs []string
+	for _, c := range jar.Cookies(mustParse(t, rawURL)) {
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	return strings.Join(pairs, "; ")
+}
+
+func TestDomainMatch(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		want         bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.example.com", "example.com", true},
+		{"a.b.example.com", "example.com", true},
+		{"example.com", "www.example.com", false},
+		{"badexample.com", "example.com", false},
+		{"example.com.evil", "example.com", false},
+		{"192.168.0.1", "192.168.0.1", true},
+		{"192.168.0.1", "168.0.1", false},
+	}
+	for _, test := range tests {
+		if got := domainMatch(test.host, test.domain); got != test.want {
+			t.Errorf("domainMatch(%q, %q) = %v, want %v", test.host, test.domain, got, test.want)
+		}
+	}
+}
+
+func TestPathMatch(t *testing.T) {
+	tests := []struct {
+		path, cookiePath string
+		want             bool
+	}{
+		{"/", "/", true},
+		{"/docs", "/", true},
+		{"/docs", "/docs", true},
+		{"/docs/", "/docs", true},
+		{"/docs/web", "/docs", true},
+		{"/docs/web", "/docs/", true},
+		{"/docsets", "/docs", false},
+		{"/doc", "/docs", false},
+		{"/", "/docs", false},
+	}
+	for _, test := range tests {
+		if got := pathMatch(test.path, test.cookiePath); got != test.want {
+			t.Errorf("pathMatch(%q, %q) = %v, want %v", test.path, test.cookiePath, got, test.want)
+		}
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"", "/"},
+		{"/", "/"},
+		{"/index.html", "/"},
+		{"/docs/index.html", "/docs"},
+		{"/docs/web/", "/docs/web"},
+		{"relative", "/"},
+	}
+	for _, test := range tests {
+		if got := defaultPath(test.path); got != test.want {
+			t.Errorf("defaultPath(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestJarDomains(t *testing.T) {
+	tests := []struct {
+		name   string
+		setURL string
+		cookie string
+		sendTo map[string]bool
+	}{
+		{"host-only", "http://www.example.com/", "a=1",
+			map[string]bool{"http://www.example.com/": true, "http://example.com/": false, "http://sub.www.example.com/": false}},
+		{"domain", "http://www.example.com/", "a=1; Domain=example.com",
+			map[string]bool{"http://www.example.com/": true, "http://example.com/": true, "http://other.example.com/": true, "http://example.org/": false}},
+		{"leading dot", "http://www.example.com/", "a=1; Domain=.Example.COM",
+			map[string]bool{"http://other.example.com/": true}},
+		{"foreign domain", "http://www.example.com/", "a=1; Domain=example.org",
+			map[string]bool{"http://www.example.com/": false, "http://example.org/": false}},
+		{"sibling domain", "http://www.example.com/", "a=1; Domain=other.example.com",
+			map[string]bool{"http://other.example.com/": false}},
+		{"public suffix", "http://www.example.co.uk/", "a=1; Domain=co.uk",
+			map[string]bool{"http://www.example.co.uk/": false, "http://other.co.uk/": false}},
+		{"public suffix host", "http://github.io/", "a=1; Domain=github.io",
+			map[string]bool{"http://github.io/": true, "http://user.github.io/": false}},
+		{"path", "http://example.com/docs/index.html", "a=1; Path=/docs",
+			map[string]bool{"http://example.com/docs": true, "http://example.com/docs/web": true, "http://example.com/": false, "http://example.com/docsets": false}},
+		{"default path", "http://example.com/docs/index.html", "a=1",
+			map[string]bool{"http://example.com/docs/other": true, "http://example.com/": false}},
+		{"secure", "https://example.com/", "a=1; Secure",
+			map[string]bool{"https://example.com/": true, "http://example.com/": false}},
+		{"secure from http", "http://example.com/", "a=1; Secure",
+			map[string]bool{"https://example.com/": false}},
+		{"port", "http://example.com:8080/", "a=1",
+			map[string]bool{"http://example.com/": true, "http://EXAMPLE.com:9090/": true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jar := NewJar(filepath.Join(t.TempDir(), "cookies.json"))
+			setCookie(t, jar, test.setURL, test.cookie)
+			for rawURL, want := range test.sendTo {
+				if got := cookieHeader(t, jar, rawURL) == "a=1"; got != want {
+					t.Errorf("sent to %s = %v, want %v", rawURL, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJarOrder(t *testing.T) {
+	jar := NewJar(filepath.Join(t.TempDir(), "cookies.json"))
+	setCookie(t, jar, "http://example.com/", "a=1; Path=/", "b=2; Path=/docs/web", "c=3; Path=/docs")
+
+	if got, want := cookieHeader(t, jar, "http://example.com/docs/web/page"), "b=2; c=3; a=1"; got != want {
+		t.Errorf("Cookie = %q, want %q", got, want)
+	}
+}
+
+func TestJarExpiry(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name       string
+		cookies    []string
+		want       string
+		persistent bool
+	}{
+		{"session", []string{"a=1"}, "a=1", false},
+		{"max-age", []string{"a=1; Max-Age=3600"}, "a=1", true},
+		{"expires", []string{"a=1; Expires=" + future}, "a=1", true},
+		{"expired", []string{"a=1; Expires=" + past}, "", false},
+		{"max-age over expires", []string{"a=1; Max-Age=3600; Expires=" + past}, "a=1", true},
+		{"max-age zero", []string{"a=1; Max-Age=3600", "a=2; Max-Age=0"}, "", false},
+		{"deleted by expires", []string{"a=1; Max-Age=3600", "a=2; Expires=" + past}, "", false},
+		{"replaced", []string{"a=1; Max-Age=3600", "a=2"}, "a=2", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jar := NewJar(filepath.Join(t.TempDir(), "cookies.json"))
+			for _, line := range test.cookies {
+				setCookie(t, jar, "http://example.com/", line)
+			}
+
+			if got := cookieHeader(t, jar, "http://example.com/"); got != test.want {
+				t.Errorf("Cookie = %q, want %q", got, test.want)
+			}
+			for _, c := range jar.SiteCookies("example.com") {
+				if c.Persistent() != test.persistent {
+					t.Errorf("Persistent() = %v, want %v", c.Persistent(), test.persistent)
+				}
+			}
+		})
+	}
+}
+
+func TestJarPersistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cookies.json")
+	jar := NewJar(file)
+	setCookie(t, jar, "https://example.com/", "session=1", "kept=2; Max-Age=3600; Secure; SameSite=Lax", "short=3; Max-Age=1")
+
+	jar.mu.Lock()
+	jar.cookies[(&Cookie{Domain: "example.com", Path: "/", Name: "short"}).key()].Expires = time.Now().Add(-time.Second)
+	jar.save()
+	jar.mu.Unlock()
+
+	reloaded := NewJar(file)
+	if got, want := cookieHeader(t, reloaded, "https://example.com/"), "kept=2"; got != want {
+		t.Fatalf("Cookie after reload = %q, want %q", got, want)
+	}
+	c := reloaded.SiteCookies("example.com")[0]
+	if !c.HostOnly || !c.Secure || c.SameSite != "lax" || c.Path != "/" {
+		t.Errorf("reloaded cookie = %+v", c)
+	}
+
+	reloaded.Clear("example.com")
+	if got := cookieHeader(t, NewJar(file), "https://example.com/"); got != "" {
+		t.Errorf("Cookie after Clear = %q, want none", got)
+	}
+}
+
+// roundTripFunc answers requests in tests without a server.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestJarSameSite(t *testing.T) {
+	jar := NewJar(filepath.Join(t.TempDir(), "cookies.json"))
+	setCookie(t, jar, "https://example.com/", "none=1; SameSite=None; Secure", "lax=2; SameSite=Lax", "strict=3; SameSite=Strict", "plain=4")
+
+	var sent []string
+	transport := &cookieTransport{jar: jar, base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = nil
+		for _, c := range req.Cookies() {
+			sent = append(sent, c.Name)
+		}
+		sort.Strings(sent)
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: http.NoBody, Request: req}, nil
+	})}
+
+	tests := []struct {
+		name   string
+		method string
+		ctx    context.Context
+		want   string
+	}{
+		{"user", "GET", context.Background(), "lax none plain strict"},
+		{"user post", "POST", context.Background(), "lax none plain strict"},
+		{"same-site subresource", "GET", WithFirstParty(context.Background(), "https://www.example.com/"), "lax none plain strict"},
+		{"cross-site subresource", "GET", WithFirstParty(context.Background(), "https://evil.com/"), "none plain"},
+		{"local page subresource", "GET", WithFirstParty(context.Background(), "file:///tmp/page.html"), "none plain"},
+		{"same-site navigation", "POST", WithNavigation(context.Background(), "https://www.example.com/"), "lax none plain strict"},
+		{"cross-site navigation", "GET", WithNavigation(context.Background(), "https://evil.com/"), "lax none plain"},
+		{"cross-site post", "POST", WithNavigation(context.Background(), "https://evil.com/"), "none plain"},
+		{"subresource of navigation", "GET", WithFirstParty(WithNavigation(context.Background(), "https://evil.com/"), "https://evil.com/"), "none plain"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(test.ctx, test.method, "https://example.com/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(sent, " "); got != test.want {
+				t.Errorf("sent %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// Failure is why a request got no response.
+type Failure int
+
+const (
+	FailureOther Failure = iota
+	FailureCanceled
+	FailureDNS
+	FailureRefused
+	FailureTimeout
+	FailureTLS
+	FailureRedirects
+)
+
+// Classify tells what kind of failure err, returned by a request, is.
+func Classify(err error) Failure {
+	var (
+		dnsErr       *net.DNSError
+		certErr      *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		netErr       net.Error
+	)
+
+	switch {
+	case errors.Is(err, ErrTooManyRedirects):
+		return FailureRedirects
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsTimeout {
+			return FailureTimeout
+		}
+		return FailureDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureRefused
+	case errors.As(err, &certErr), errors.As(err, &authorityErr), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return FailureTLS
+	case errors.Is(err, context.Canceled):
+		return FailureCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	}
+	return FailureOther
+}
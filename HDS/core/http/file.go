@@ -0,0 +1,220 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// fileTransport serves file:// URLs from the local disk, directories as a
+// generated listing. Only local pages may load local files, and nothing
+// may redirect to them.
+type fileTransport struct{}
+
+func (fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if page, _ := req.Context().Value(firstPartyKey{}).(*url.URL); page != nil && page.Scheme != "file" {
+		return fileStatus(req, http.StatusForbidden), nil
+	}
+	if req.Response != nil && req.Response.Request.URL.Scheme != "file" {
+		return fileStatus(req, http.StatusForbidden), nil
+	}
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return fileStatus(req, http.StatusMethodNotAllowed), nil
+	}
+	if host := req.URL.Host; host != "" && host != "localhost" {
+		return fileStatus(req, http.StatusNotFound), nil
+	}
+
+	name := FilePath(req.URL)
+	info, err := os.Stat(name)
+	switch {
+	case os.IsNotExist(err):
+		return fileStatus(req, http.StatusNotFound), nil
+	case os.IsPermission(err):
+		return fileStatus(req, http.StatusForbidden), nil
+	case err != nil:
+		return nil, err
+	}
+
+	if info.IsDir() {
+		// the listing links relative to the directory, so it needs the slash
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			resp := fileStatus(req, http.StatusMovedPermanently)
+			location := *req.URL
+			location.Path += "/"
+			resp.Header.Set("Location", location.String())
+			return resp, nil
+		}
+
+		listing, err := directoryListing(name, req.URL.Path)
+		if err != nil {
+			if os.IsPermission(err) {
+				return fileStatus(req, http.StatusForbidden), nil
+			}
+			return nil, err
+		}
+		return fileResponse(req, "text/html; charset=utf-8", int64(len(listing)), io.NopCloser(strings.NewReader(listing))), nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		if os.IsPermission(err) {
+			return fileStatus(req, http.StatusForbidden), nil
+		}
+		return nil, err
+	}
+
+	contentType, err := sniffType(file, name)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return fileResponse(req, contentType, info.Size(), file), nil
+}
+
+// FilePath is the local path a file:// URL names.
+func FilePath(u *url.URL) string {
+	path := u.Path
+	// file:///C:/dir is C:\dir
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// FileURL turns an absolute local path into a file:// URL.
+func FileURL(path string) string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// sniffType is the type the extension of name says, or what its first
+// bytes look like when the extension is unknown. Both guess a charset
+// along, it is left out so the page's BOM or <meta> decides.
+func sniffType(file *os.File, name string) (string, error) {
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(file, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return "", err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		contentType = http.DetectContentType(head[:n])
+	}
+
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "application/octet-stream", nil
+	}
+	return media, nil
+}
+
+func fileResponse(req *http.Request, contentType string, size int64, body io.ReadCloser) *http.Response {
+	resp := &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		ContentLength: size,
+		Body:          body,
+		Request:       req,
+	}
+	resp.Header.Set("Content-Type", contentType)
+	if req.Method == "HEAD" {
+		body.Close()
+		resp.Body = http.NoBody
+	}
+	return resp
+}
+
+func fileStatus(req *http.Request, code int) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode: code,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// directoryListing is a page linking the entries of dir, subdirectories
+// first.
+func directoryListing(dir, urlPath string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].IsDir() != entries[b].IsDir() {
+			return entries[a].IsDir()
+		}
+		return strings.ToLower(entries[a].Name()) < strings.ToLower(entries[b].Name())
+	})
+
+	title := html.EscapeString("Index of " + urlPath)
+	var page bytes.Buffer
+	page.WriteString("<!DOCTYPE html><html><head><title>" + title + "</title></head><body><h1>" + title + "</h1>")
+	page.WriteString("<table><tr><th>Name</th><th>Size</th><th>Modified</th></tr>")
+	if urlPath != "/" {
+		page.WriteString(`<tr><td><a href="../">../</a></td><td></td><td></td></tr>`)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		size, modified := "", ""
+		if info, err := entry.Info(); err == nil {
+			modified = info.ModTime().Format("2006-01-02 15:04")
+			if !entry.IsDir() {
+				size = formatSize(info.Size())
+			}
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+
+		// "./" keeps names with a colon from reading as a scheme
+		href := "./" + url.PathEscape(entry.Name())
+		if entry.IsDir() {
+			href += "/"
+		}
+		fmt.Fprintf(&page, `<tr><td><a href="%s">%s</a></td><td>%s</td><td>%s</td></tr>`,
+			html.EscapeString(href), html.EscapeString(name), size, modified)
+	}
+
+	page.WriteString("</table></body></html>")
+	return page.String(), nil
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[exp])
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// windows1251 encodes the ASCII and Cyrillic letters of s, which windows-1251
+// keeps at 0xC0 to 0xFF.
+func windows1251(s string) []byte {
+	var encoded []byte
+	for _, r := range s {
+		if r >= 'А' && r <= 'я' {
+			encoded = append(encoded, byte(r-'А'+0xC0))
+		} else {
+			encoded = append(encoded, byte(r))
+		}
+	}
+	return encoded
+}
+
+func TestFileCharset(t *testing.T) {
+	const text = "Привет, мир"
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"html extension", "page.html"},
+		{"htm extension", "page.htm"},
+		{"sniffed", "page"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := windows1251(`<!DOCTYPE html><html><head><meta charset="windows-1251"></head><body>` + text + `</body></html>`)
+			name := filepath.Join(t.TempDir(), test.file)
+			if err := os.WriteFile(name, page, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("GET", FileURL(name), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := fileTransport{}.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contentType := resp.Header.Get("Content-Type")
+			if contentType != "text/html" {
+				t.Errorf("Content-Type = %q, want text/html", contentType)
+			}
+			decoded, charset := decodeText(body, contentType, req.URL.String())
+			if charset != "windows-1251" || !strings.Contains(decoded, text) {
+				t.Errorf("decoded as %s to %q", charset, decoded)
+			}
+		})
+	}
+}
@@ -1,45 +1,125 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"io"
-	"log"
+	"net"
 	"net/http"
+	"os"
+	"time"
 )
 
+// Response is what a request got back. Page and Charset are filled in by
+// ReadPage, before that Body streams the response and must be closed.
 type Response struct {
 	UserAgent string
 	Page      string
 	Done      bool
+
+	// Charset the page was decoded from, empty for binary bodies which
+	// are left as they are.
+	Charset string
+
+	StatusCode int
+	Status     string
+	Header     http.Header
+
+	// URL is where the redirects in Redirects ended up.
+	URL       string
+	Redirects []Redirect
+
+	// TLS is nil for plain http and responses served from the cache.
+	TLS    *tls.ConnectionState
+	Timing *Timing
+
+	Body io.ReadCloser
 }
 
-func GETRequest(adress string, Ua string) (*Response, error) {
+// ConnectTimeout bounds dialing a server, ReadTimeout how long a request may
+// go without receiving anything. HIMERA_CONNECT_TIMEOUT and
+// HIMERA_READ_TIMEOUT override them, as Go durations ("15s").
+var (
+	ConnectTimeout = durationEnv("HIMERA_CONNECT_TIMEOUT", 10*time.Second)
+	ReadTimeout    = durationEnv("HIMERA_READ_TIMEOUT", 30*time.Second)
+)
 
-	req, err := http.NewRequest("GET", adress, nil)
-	if err != nil {
-		log.Printf("HTTP request creation error: %v", err)
-		return nil, err
+// client is shared by every request so connections, cookies and the
+// cache are too.
+var client = &http.Client{
+	CheckRedirect: checkRedirect,
+	Transport: &cookieTransport{
+		jar: Cookies,
+		base: &cacheTransport{
+			cache: Cache,
+			base:  newTransport(),
+		},
+	},
+}
+
+func newTransport() *http.Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: ConnectTimeout, KeepAlive: 30 * time.Second}
+			return dialer.DialContext(ctx, network, address)
+		},
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
 	}
+	transport.RegisterProtocol("file", fileTransport{})
+	return transport
+}
+
+func GETRequest(adress string, Ua string) (*Response, error) {
+	return GETRequestContext(context.Background(), adress, Ua)
+}
+
+// GETRequestContext is GETRequest that gives up when ctx is cancelled, or
+// when the server stays silent for ReadTimeout.
+func GETRequestContext(ctx context.Context, adress string, Ua string) (*Response, error) {
+	return readPage(NewRequest("GET", adress).WithContext(ctx).WithUserAgent(Ua).Do())
+}
 
-	req.Header.Set("User-Agent", Ua)
+// POSTRequestContext sends body as contentType to adress, the way a form
+// is submitted.
+func POSTRequestContext(ctx context.Context, adress string, contentType string, body []byte, Ua string) (*Response, error) {
+	return readPage(NewRequest("POST", adress).WithContext(ctx).WithUserAgent(Ua).WithBody(contentType, bytes.NewReader(body)).Do())
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func readPage(resp *Response, err error) (*Response, error) {
 	if err != nil {
-		log.Printf("HTTP request error: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Read error: %v", err)
+	if err := resp.ReadPage(); err != nil {
 		return nil, err
 	}
-	htmlString := string(bodyBytes)
+	return resp, nil
+}
+
+type readTimeoutError struct {
+	after time.Duration
+}
+
+func (e readTimeoutError) Error() string { return "read timeout: no data for " + e.after.String() }
+func (readTimeoutError) Timeout() bool   { return true }
+func (readTimeoutError) Temporary() bool { return true }
+
+// timeoutError tells a cancellation by the read timeout apart from one by
+// the caller.
+func timeoutError(err error, idle *time.Timer, timeout time.Duration) error {
+	if !idle.Stop() && err != nil {
+		return readTimeoutError{timeout}
+	}
+	return err
+}
 
-	return &Response{
-		UserAgent: Ua,
-		Page:      htmlString,
-		Done:      true,
-	}, nil
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
 }
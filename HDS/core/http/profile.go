@@ -0,0 +1,41 @@
+package http
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ProfileDir is where cookies and the cache are kept between runs,
+// HIMERA_PROFILE overrides the default under the user config directory.
+func ProfileDir() string {
+	if dir := os.Getenv("HIMERA_PROFILE"); dir != "" {
+		return dir
+	}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "himera")
+	}
+	return filepath.Join(os.TempDir(), "himera")
+}
+
+// writeFileAtomic replaces name with data, readers never see half a file.
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
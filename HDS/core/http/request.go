@@ -0,0 +1,257 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+)
+
+// MaxRedirects is how many redirects a request follows before giving up.
+const MaxRedirects = 10
+
+// ErrTooManyRedirects is returned, wrapped, when a request was redirected
+// more than MaxRedirects times.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// Request is an HTTP request to be sent with Do. NewRequest starts one and
+// the With methods fill it in:
+//
+//	resp, err := NewRequest("PUT", address).
+//		WithHeader("Accept", "application/json").
+//		WithBody("application/json", body).
+//		WithTimeout(5 * time.Second).
+//		Do()
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   io.Reader
+
+	// Timeout bounds the whole request including reading the body, zero
+	// means none. ReadTimeout is how long it may go without receiving
+	// anything, the package ReadTimeout when zero.
+	Timeout     time.Duration
+	ReadTimeout time.Duration
+
+	ctx context.Context
+}
+
+// Redirect is one hop of a redirect chain, the URL that answered with
+// StatusCode.
+type Redirect struct {
+	URL        string
+	StatusCode int
+}
+
+// Timing is how long the phases of a request took since Start. DNS,
+// Connect and TLS are zero when a kept-alive connection or the cache was
+// used, Done is set once the body has been read to the end.
+type Timing struct {
+	Start     time.Time
+	DNS       time.Duration
+	Connect   time.Duration
+	TLS       time.Duration
+	FirstByte time.Duration
+	Done      time.Duration
+}
+
+func NewRequest(method, address string) *Request {
+	return &Request{
+		Method: strings.ToUpper(method),
+		URL:    address,
+		Header: make(http.Header),
+		ctx:    context.Background(),
+	}
+}
+
+func (r *Request) WithHeader(name, value string) *Request {
+	r.Header.Add(name, value)
+	return r
+}
+
+func (r *Request) WithUserAgent(ua string) *Request {
+	r.Header.Set("User-Agent", ua)
+	return r
+}
+
+// WithBody sends body as contentType, an empty contentType leaves the
+// header out.
+func (r *Request) WithBody(contentType string, body io.Reader) *Request {
+	r.Body = body
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func (r *Request) WithTimeout(timeout time.Duration) *Request {
+	r.Timeout = timeout
+	return r
+}
+
+func (r *Request) WithReadTimeout(timeout time.Duration) *Request {
+	r.ReadTimeout = timeout
+	return r
+}
+
+// WithContext makes the request give up when ctx is cancelled.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+type traceKey struct{}
+
+// requestTrace collects what the shared client learns about a request
+// while following its redirects.
+type requestTrace struct {
+	redirects []Redirect
+}
+
+// Do sends the request. Any status is a response, errors are only for
+// requests that got none. The caller must close the response Body.
+func (r *Request) Do() (*Response, error) {
+	readTimeout := r.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = ReadTimeout
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if r.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(r.ctx, r.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(r.ctx)
+	}
+	idle := time.AfterFunc(readTimeout, cancel)
+
+	trace := &requestTrace{}
+	timing := &Timing{Start: time.Now()}
+	var dnsStart, connectStart, tlsStart time.Time
+	ctx = httptrace.WithClientTrace(context.WithValue(ctx, traceKey{}, trace), &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { timing.DNS = time.Since(dnsStart) },
+		ConnectStart:         func(string, string) { connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { timing.Connect = time.Since(connectStart) },
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { timing.TLS = time.Since(tlsStart) },
+		GotFirstResponseByte: func() { timing.FirstByte = time.Since(timing.Start) },
+	})
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, r.Body)
+	if err != nil {
+		idle.Stop()
+		cancel()
+		log.Printf("HTTP request creation error: %v", err)
+		return nil, err
+	}
+	for name, values := range r.Header {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		err = timeoutError(err, idle, readTimeout)
+		cancel()
+		log.Printf("HTTP request error: %v", err)
+		return nil, err
+	}
+	if timing.FirstByte == 0 {
+		timing.FirstByte = time.Since(timing.Start)
+	}
+
+	return &Response{
+		UserAgent:  r.Header.Get("User-Agent"),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		URL:        resp.Request.URL.String(),
+		Redirects:  trace.redirects,
+		TLS:        resp.TLS,
+		Timing:     timing,
+		Body:       &responseBody{ReadCloser: resp.Body, idle: idle, timeout: readTimeout, cancel: cancel, timing: timing},
+	}, nil
+}
+
+// checkRedirect records the redirect chain of a request and stops it after
+// MaxRedirects hops.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if trace, ok := req.Context().Value(traceKey{}).(*requestTrace); ok && req.Response != nil {
+		trace.redirects = append(trace.redirects, Redirect{URL: via[len(via)-1].URL.String(), StatusCode: req.Response.StatusCode})
+	}
+	if len(via) > MaxRedirects {
+		return ErrTooManyRedirects
+	}
+	return nil
+}
+
+// responseBody streams a response, pushing the read timeout back whenever
+// data arrives. Closing it releases the request.
+type responseBody struct {
+	io.ReadCloser
+	idle    *time.Timer
+	timeout time.Duration
+	cancel  context.CancelFunc
+	timing  *Timing
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.idle.Reset(b.timeout)
+	}
+	switch {
+	case err == io.EOF:
+		if b.timing.Done == 0 {
+			b.timing.Done = time.Since(b.timing.Start)
+		}
+	case err != nil:
+		err = timeoutError(err, b.idle, b.timeout)
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	b.idle.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// ContentType is the media type of the response, lower case and without
+// parameters. It is sniffed from Page when the server sent none.
+func (r *Response) ContentType() string {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" && r.Page != "" {
+		contentType = http.DetectContentType([]byte(r.Page))
+	}
+
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return media
+}
+
+// ReadPage reads the whole body into Page, decoded to UTF-8 when it is
+// text, and closes it.
+func (r *Response) ReadPage() error {
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Read error: %v", err)
+		return err
+	}
+
+	r.Page, r.Charset = decodeText(body, r.Header.Get("Content-Type"), r.URL)
+	r.Done = true
+	return nil
+}
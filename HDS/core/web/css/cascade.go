@@ -0,0 +1,348 @@
+package css
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type Media struct {
+	Width    float32
+	Height   float32
+	FontSize float32
+}
+
+// Style holds the computed values of one element. Inherited properties that
+// the element does not set are looked up on the parent style.
+type Style struct {
+	values map[string]string
+	parent *Style
+}
+
+var inheritedProperties = map[string]bool{
+	"color":           true,
+	"cursor":          true,
+	"direction":       true,
+	"font-family":     true,
+	"font-size":       true,
+	"font-style":      true,
+	"font-variant":    true,
+	"font-weight":     true,
+	"letter-spacing":  true,
+	"line-height":     true,
+	"list-style-type": true,
+	"quotes":          true,
+	"text-align":      true,
+	"text-indent":     true,
+	"text-transform":  true,
+	"visibility":      true,
+	"white-space":     true,
+	"word-spacing":    true,
+}
+
+func (s *Style) Get(property string) string {
+	for style := s; style != nil; style = style.parent {
+		if value, ok := style.values[property]; ok {
+			return value
+		}
+		if !inheritedProperties[property] {
+			break
+		}
+	}
+	return ""
+}
+
+func (s *Style) Specified(property string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	value, ok := s.values[property]
+	return value, ok
+}
+
+type matchedDeclaration struct {
+	Declaration
+	origin      Origin
+	specificity Specificity
+	order       int
+}
+
+func (m matchedDeclaration) precedence() int {
+	switch {
+	case m.origin == UserAgentOrigin && !m.Important:
+		return 0
+	case m.origin == AuthorOrigin && !m.Important:
+		return 1
+	case m.origin == AuthorOrigin:
+		return 2
+	}
+	return 3
+}
+
+type indexedRule struct {
+	selector *Selector
+	rule     *Rule
+	origin   Origin
+	order    int
+}
+
+type ruleIndex struct {
+	byID    map[string][]indexedRule
+	byClass map[string][]indexedRule
+	byTag   map[string][]indexedRule
+	other   []indexedRule
+}
+
+func newRuleIndex(sheets []*Stylesheet, media Media) *ruleIndex {
+	index := &ruleIndex{
+		byID:    make(map[string][]indexedRule),
+		byClass: make(map[string][]indexedRule),
+		byTag:   make(map[string][]indexedRule),
+	}
+
+	order := 0
+	for _, sheet := range sheets {
+		for _, rule := range sheet.Rules {
+			if rule.Media != "" && !MatchMedia(rule.Media, media) {
+				continue
+			}
+			for _, selector := range rule.Selectors {
+				entry := indexedRule{selector: selector, rule: rule, origin: sheet.Origin, order: order}
+				order++
+
+				key := selector.Compounds[len(selector.Compounds)-1]
+				switch {
+				case key.ID != "":
+					index.byID[key.ID] = append(index.byID[key.ID], entry)
+				case len(key.Classes) > 0:
+					index.byClass[key.Classes[0]] = append(index.byClass[key.Classes[0]], entry)
+				case key.Tag != "" && key.Tag != "*":
+					index.byTag[key.Tag] = append(index.byTag[key.Tag], entry)
+				default:
+					index.other = append(index.other, entry)
+				}
+			}
+		}
+	}
+
+	return index
+}
+
+func (index *ruleIndex) candidates(node *html.Node) []indexedRule {
+	candidates := append([]indexedRule(nil), index.other...)
+	candidates = append(candidates, index.byTag[strings.ToLower(node.Data)]...)
+
+	if id := attr(node, "id"); id != "" {
+		candidates = append(candidates, index.byID[id]...)
+	}
+
+	seen := make(map[string]bool)
+	for _, class := range strings.Fields(attr(node, "class")) {
+		if !seen[class] {
+			seen[class] = true
+			candidates = append(candidates, index.byClass[class]...)
+		}
+	}
+
+	return candidates
+}
+
+// Compute runs the cascade over the document and returns the computed style
+// of every element.
+func Compute(doc *html.Node, sheets []*Stylesheet, media Media) map[*html.Node]*Style {
+	styles := make(map[*html.Node]*Style)
+	index := newRuleIndex(sheets, media)
+	root := &Style{values: map[string]string{"font-size": formatPx(media.FontSize)}}
+	rootSize := media.FontSize
+
+	var walk func(node *html.Node, parent *Style)
+	walk = func(node *html.Node, parent *Style) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			style := computeElement(child, parent, index, media, rootSize)
+			styles[child] = style
+			if parent == root {
+				if length, ok := ParseLength(style.Get("font-size")); ok {
+					rootSize = length.Value
+				}
+			}
+			walk(child, style)
+		}
+	}
+	walk(doc, root)
+
+	return styles
+}
+
+func computeElement(node *html.Node, parent *Style, index *ruleIndex, media Media, rootSize float32) *Style {
+	var matched []matchedDeclaration
+
+	for _, entry := range index.candidates(node) {
+		if !entry.selector.Matches(node) {
+			continue
+		}
+		for _, decl := range entry.rule.Declarations {
+			matched = append(matched, matchedDeclaration{
+				Declaration: decl,
+				origin:      entry.origin,
+				specificity: entry.selector.Specificity,
+				order:       entry.order,
+			})
+		}
+	}
+
+	if inline, ok := lookupAttr(node, "style"); ok {
+		for _, decl := range ParseDeclarations(inline) {
+			matched = append(matched, matchedDeclaration{
+				Declaration: decl,
+				origin:      AuthorOrigin,
+				specificity: Specificity{1 << 16, 0, 0},
+				order:       1 << 30,
+			})
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if a.precedence() != b.precedence() {
+			return a.precedence() < b.precedence()
+		}
+		if a.specificity != b.specificity {
+			return a.specificity.Less(b.specificity)
+		}
+		return a.order < b.order
+	})
+
+	style := &Style{values: make(map[string]string, len(matched)), parent: parent}
+	for _, decl := range matched {
+		style.values[decl.Property] = decl.Value
+	}
+
+	for property, value := range style.values {
+		switch strings.ToLower(value) {
+		case "inherit":
+			if inherited := parent.Get(property); inherited != "" {
+				style.values[property] = inherited
+			} else {
+				delete(style.values, property)
+			}
+		case "initial", "unset", "revert":
+			delete(style.values, property)
+		}
+	}
+
+	if size, ok := style.values["font-size"]; ok {
+		if px, ok := computeFontSize(size, parent.Get("font-size"), rootSize, media); ok {
+			style.values["font-size"] = formatPx(px)
+		} else {
+			delete(style.values, "font-size")
+		}
+	}
+
+	return style
+}
+
+var fontSizeKeywords = map[string]float32{
+	"xx-small":  3.0 / 5,
+	"x-small":   3.0 / 4,
+	"small":     8.0 / 9,
+	"medium":    1,
+	"large":     6.0 / 5,
+	"x-large":   3.0 / 2,
+	"xx-large":  2,
+	"xxx-large": 3,
+}
+
+func computeFontSize(value, parentValue string, rootSize float32, media Media) (float32, bool) {
+	parentSize := media.FontSize
+	if parent, ok := ParseLength(parentValue); ok && parent.Unit == "px" {
+		parentSize = parent.Value
+	}
+
+	value = strings.ToLower(value)
+	if ratio, ok := fontSizeKeywords[value]; ok {
+		return media.FontSize * ratio, true
+	}
+
+	switch value {
+	case "smaller":
+		return parentSize / 1.2, true
+	case "larger":
+		return parentSize * 1.2, true
+	}
+
+	return ToPx(value, parentSize, rootSize, parentSize, media)
+}
+
+func formatPx(px float32) string {
+	return strconv.FormatFloat(float64(px), 'f', -1, 32) + "px"
+}
+
+// MatchMedia evaluates a media query list against the viewport. Only the
+// media type and min/max-width features are understood.
+func MatchMedia(query string, media Media) bool {
+	for _, alternative := range strings.Split(strings.ToLower(query), ",") {
+		if matchMediaQuery(strings.TrimSpace(alternative), media) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchMediaQuery(query string, media Media) bool {
+	negate := false
+	if strings.HasPrefix(query, "not ") {
+		negate = true
+		query = strings.TrimPrefix(query, "not ")
+	}
+	query = strings.TrimPrefix(query, "only ")
+
+	result := true
+	for _, part := range strings.Split(query, " and ") {
+		part = strings.TrimSpace(part)
+
+		switch {
+		case part == "" || part == "all" || part == "screen":
+		case strings.HasPrefix(part, "(") && strings.HasSuffix(part, ")"):
+			result = result && matchMediaFeature(part[1:len(part)-1], media)
+		default:
+			result = false
+		}
+	}
+
+	return result != negate
+}
+
+func matchMediaFeature(feature string, media Media) bool {
+	name, value, found := strings.Cut(feature, ":")
+	if !found {
+		return false
+	}
+
+	length, ok := ParseLength(value)
+	if !ok {
+		return false
+	}
+
+	limit := length.Value
+	switch length.Unit {
+	case "em", "rem":
+		limit *= media.FontSize
+	case "px", "":
+	default:
+		return false
+	}
+
+	switch strings.TrimSpace(name) {
+	case "min-width":
+		return media.Width >= limit
+	case "max-width":
+		return media.Width <= limit
+	}
+
+	return false
+}
@@ -0,0 +1,267 @@
+package css
+
+import (
+	"strings"
+)
+
+type Origin int
+
+const (
+	UserAgentOrigin Origin = iota
+	AuthorOrigin
+)
+
+type Declaration struct {
+	Property  string
+	Value     string
+	Important bool
+}
+
+type Rule struct {
+	Selectors    []*Selector
+	Declarations []Declaration
+	Media        string
+}
+
+type Stylesheet struct {
+	Origin  Origin
+	Rules   []*Rule
+	Imports []string
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+}
+
+func Parse(src string, origin Origin) *Stylesheet {
+	p := &parser{tokens: Tokenize(src)}
+	sheet := &Stylesheet{Origin: origin}
+	p.parseRules(sheet, "", false)
+
+	return sheet
+}
+
+func ParseDeclarations(src string) []Declaration {
+	return parseDeclarationList(Tokenize(src))
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) parseRules(sheet *Stylesheet, media string, nested bool) {
+	for !p.done() {
+		tok := p.tokens[p.pos]
+
+		switch tok.Type {
+		case WhitespaceToken, SemicolonToken:
+			p.pos++
+		case RightBraceToken:
+			p.pos++
+			if nested {
+				return
+			}
+		case AtKeywordToken:
+			p.parseAtRule(sheet, media)
+		default:
+			p.parseQualifiedRule(sheet, media)
+		}
+	}
+}
+
+func (p *parser) parseAtRule(sheet *Stylesheet, media string) {
+	name := strings.ToLower(p.tokens[p.pos].Value)
+	p.pos++
+
+	prelude := p.consumePrelude()
+	if p.done() || p.tokens[p.pos].Type == SemicolonToken {
+		p.pos++
+		if name == "import" {
+			if href := importURL(prelude); href != "" {
+				sheet.Imports = append(sheet.Imports, href)
+			}
+		}
+		return
+	}
+
+	// p.tokens[p.pos] is the opening brace
+	p.pos++
+
+	switch name {
+	case "media":
+		query := strings.TrimSpace(serialize(prelude))
+		if media != "" {
+			query = media + " and " + query
+		}
+		p.parseRules(sheet, query, true)
+	case "supports", "layer", "document":
+		p.parseRules(sheet, media, true)
+	default:
+		p.skipBlock()
+	}
+}
+
+func (p *parser) parseQualifiedRule(sheet *Stylesheet, media string) {
+	prelude := p.consumePrelude()
+	if p.done() || p.tokens[p.pos].Type != LeftBraceToken {
+		p.pos++
+		return
+	}
+	p.pos++
+
+	block := p.consumeBlock()
+
+	selectors, ok := ParseSelectors(prelude)
+	if !ok {
+		return
+	}
+
+	sheet.Rules = append(sheet.Rules, &Rule{
+		Selectors:    selectors,
+		Declarations: parseDeclarationList(block),
+		Media:        media,
+	})
+}
+
+func (p *parser) consumePrelude() []Token {
+	start := p.pos
+	depth := 0
+
+	for !p.done() {
+		switch p.tokens[p.pos].Type {
+		case LeftParenToken, FunctionToken, LeftBracketToken:
+			depth++
+		case RightParenToken, RightBracketToken:
+			depth--
+		case LeftBraceToken:
+			return p.tokens[start:p.pos]
+		case SemicolonToken:
+			if depth <= 0 {
+				return p.tokens[start:p.pos]
+			}
+		}
+		p.pos++
+	}
+
+	return p.tokens[start:p.pos]
+}
+
+func (p *parser) consumeBlock() []Token {
+	start := p.pos
+	depth := 1
+
+	for !p.done() {
+		switch p.tokens[p.pos].Type {
+		case LeftBraceToken:
+			depth++
+		case RightBraceToken:
+			depth--
+			if depth == 0 {
+				block := p.tokens[start:p.pos]
+				p.pos++
+				return block
+			}
+		}
+		p.pos++
+	}
+
+	return p.tokens[start:]
+}
+
+func (p *parser) skipBlock() {
+	p.consumeBlock()
+}
+
+func parseDeclarationList(tokens []Token) []Declaration {
+	var declarations []Declaration
+
+	start := 0
+	depth := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) {
+			switch tokens[i].Type {
+			case LeftParenToken, FunctionToken, LeftBracketToken, LeftBraceToken:
+				depth++
+				continue
+			case RightParenToken, RightBracketToken, RightBraceToken:
+				depth--
+				continue
+			case SemicolonToken:
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		if decl, ok := parseDeclaration(tokens[start:i]); ok {
+			declarations = append(declarations, expandShorthand(decl)...)
+		}
+		start = i + 1
+	}
+
+	return declarations
+}
+
+func parseDeclaration(tokens []Token) (Declaration, bool) {
+	tokens = trimWhitespace(tokens)
+	if len(tokens) < 2 || tokens[0].Type != IdentToken {
+		return Declaration{}, false
+	}
+
+	name := strings.ToLower(tokens[0].Value)
+	rest := trimWhitespace(tokens[1:])
+	if len(rest) == 0 || rest[0].Type != ColonToken {
+		return Declaration{}, false
+	}
+	value := trimWhitespace(rest[1:])
+
+	important := false
+	if n := len(value); n >= 2 && value[n-1].Type == IdentToken && strings.EqualFold(value[n-1].Value, "important") {
+		bang := trimWhitespace(value[:n-1])
+		if m := len(bang); m > 0 && bang[m-1].Type == DelimToken && bang[m-1].Value == "!" {
+			important = true
+			value = trimWhitespace(bang[:m-1])
+		}
+	}
+
+	if len(value) == 0 {
+		return Declaration{}, false
+	}
+
+	return Declaration{Property: name, Value: serialize(value), Important: important}, true
+}
+
+func importURL(prelude []Token) string {
+	for _, tok := range prelude {
+		switch tok.Type {
+		case URLToken, StringToken:
+			return tok.Value
+		}
+	}
+	return ""
+}
+
+func trimWhitespace(tokens []Token) []Token {
+	for len(tokens) > 0 && tokens[0].Type == WhitespaceToken {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].Type == WhitespaceToken {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+func serialize(tokens []Token) string {
+	var sb strings.Builder
+	for _, tok := range tokens {
+		if tok.Type == WhitespaceToken {
+			sb.WriteByte(' ')
+			continue
+		}
+		sb.WriteString(tok.Raw)
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,621 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type Combinator byte
+
+const (
+	Descendant        Combinator = ' '
+	Child             Combinator = '>'
+	NextSibling       Combinator = '+'
+	SubsequentSibling Combinator = '~'
+)
+
+type AttrSelector struct {
+	Name     string
+	Operator string
+	Value    string
+
+	// Flag is "i" or "s" when the selector forces the value comparison to
+	// be case-insensitive or sensitive, empty otherwise.
+	Flag string
+}
+
+type PseudoClass struct {
+	Name string
+	Arg  string
+	Not  *Compound
+	A, B int
+}
+
+type Compound struct {
+	Tag           string
+	ID            string
+	Classes       []string
+	Attrs         []AttrSelector
+	Pseudos       []PseudoClass
+	PseudoElement string
+}
+
+type Specificity [3]int
+
+func (s Specificity) Less(o Specificity) bool {
+	for i := range s {
+		if s[i] != o[i] {
+			return s[i] < o[i]
+		}
+	}
+	return false
+}
+
+// Selector is a complex selector. Compounds are stored left to right,
+// Combinators[i] joins Compounds[i] and Compounds[i+1].
+type Selector struct {
+	Compounds   []*Compound
+	Combinators []Combinator
+	Specificity Specificity
+}
+
+func ParseSelectors(tokens []Token) ([]*Selector, bool) {
+	var selectors []*Selector
+
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && tokens[i].Type != CommaToken {
+			continue
+		}
+
+		selector, ok := parseSelector(trimWhitespace(tokens[start:i]))
+		if !ok {
+			return nil, false
+		}
+		selectors = append(selectors, selector)
+		start = i + 1
+	}
+
+	return selectors, len(selectors) > 0
+}
+
+func parseSelector(tokens []Token) (*Selector, bool) {
+	if len(tokens) == 0 {
+		return nil, false
+	}
+
+	selector := &Selector{}
+	pos := 0
+
+	for pos < len(tokens) {
+		compound, next, ok := parseCompound(tokens, pos)
+		if !ok {
+			return nil, false
+		}
+		selector.Compounds = append(selector.Compounds, compound)
+		pos = next
+
+		if pos >= len(tokens) {
+			break
+		}
+
+		combinator := Descendant
+		for pos < len(tokens) {
+			tok := tokens[pos]
+			if tok.Type == WhitespaceToken {
+				pos++
+				continue
+			}
+			if tok.Type == DelimToken && (tok.Value == ">" || tok.Value == "+" || tok.Value == "~") {
+				combinator = Combinator(tok.Value[0])
+				pos++
+				continue
+			}
+			break
+		}
+		if pos >= len(tokens) {
+			return nil, false
+		}
+		selector.Combinators = append(selector.Combinators, combinator)
+	}
+
+	for _, compound := range selector.Compounds {
+		selector.Specificity = selector.Specificity.add(compound.specificity())
+	}
+
+	return selector, true
+}
+
+func (s Specificity) add(o Specificity) Specificity {
+	return Specificity{s[0] + o[0], s[1] + o[1], s[2] + o[2]}
+}
+
+func (c *Compound) specificity() Specificity {
+	var s Specificity
+	if c.ID != "" {
+		s[0]++
+	}
+	s[1] += len(c.Classes) + len(c.Attrs)
+	for _, pseudo := range c.Pseudos {
+		if pseudo.Not != nil {
+			s = s.add(pseudo.Not.specificity())
+		} else {
+			s[1]++
+		}
+	}
+	if c.Tag != "" && c.Tag != "*" {
+		s[2]++
+	}
+	if c.PseudoElement != "" {
+		s[2]++
+	}
+	return s
+}
+
+func parseCompound(tokens []Token, pos int) (*Compound, int, bool) {
+	compound := &Compound{}
+	start := pos
+
+	for pos < len(tokens) {
+		tok := tokens[pos]
+
+		switch {
+		case tok.Type == IdentToken && pos == start:
+			compound.Tag = strings.ToLower(tok.Value)
+			pos++
+
+		case tok.Type == DelimToken && tok.Value == "*" && pos == start:
+			compound.Tag = "*"
+			pos++
+
+		case tok.Type == HashToken:
+			compound.ID = tok.Value
+			pos++
+
+		case tok.Type == DelimToken && tok.Value == ".":
+			if pos+1 >= len(tokens) || tokens[pos+1].Type != IdentToken {
+				return nil, pos, false
+			}
+			compound.Classes = append(compound.Classes, tokens[pos+1].Value)
+			pos += 2
+
+		case tok.Type == LeftBracketToken:
+			attr, next, ok := parseAttrSelector(tokens, pos+1)
+			if !ok {
+				return nil, pos, false
+			}
+			compound.Attrs = append(compound.Attrs, attr)
+			pos = next
+
+		case tok.Type == ColonToken:
+			if pos+1 < len(tokens) && tokens[pos+1].Type == ColonToken {
+				if pos+2 >= len(tokens) || tokens[pos+2].Type != IdentToken {
+					return nil, pos, false
+				}
+				compound.PseudoElement = strings.ToLower(tokens[pos+2].Value)
+				pos += 3
+				continue
+			}
+
+			pseudo, next, ok := parsePseudoClass(tokens, pos+1)
+			if !ok {
+				return nil, pos, false
+			}
+			// Legacy single colon pseudo-elements.
+			switch pseudo.Name {
+			case "before", "after", "first-line", "first-letter":
+				compound.PseudoElement = pseudo.Name
+			default:
+				compound.Pseudos = append(compound.Pseudos, pseudo)
+			}
+			pos = next
+
+		default:
+			return compound, pos, pos > start
+		}
+	}
+
+	return compound, pos, pos > start
+}
+
+func parseAttrSelector(tokens []Token, pos int) (AttrSelector, int, bool) {
+	var attr AttrSelector
+
+	skip := func() {
+		for pos < len(tokens) && tokens[pos].Type == WhitespaceToken {
+			pos++
+		}
+	}
+
+	skip()
+	if pos >= len(tokens) || tokens[pos].Type != IdentToken {
+		return attr, pos, false
+	}
+	attr.Name = strings.ToLower(tokens[pos].Value)
+	pos++
+	skip()
+
+	if pos < len(tokens) && tokens[pos].Type == DelimToken {
+		op := tokens[pos].Value
+		if op != "=" {
+			pos++
+			if pos >= len(tokens) || tokens[pos].Type != DelimToken || tokens[pos].Value != "=" {
+				return attr, pos, false
+			}
+			op += "="
+		}
+		attr.Operator = op
+		pos++
+		skip()
+
+		if pos >= len(tokens) || (tokens[pos].Type != IdentToken && tokens[pos].Type != StringToken) {
+			return attr, pos, false
+		}
+		attr.Value = tokens[pos].Value
+		pos++
+		skip()
+
+		if pos < len(tokens) && tokens[pos].Type == IdentToken {
+			attr.Flag = strings.ToLower(tokens[pos].Value)
+			if attr.Flag != "i" && attr.Flag != "s" {
+				return attr, pos, false
+			}
+			pos++
+			skip()
+		}
+	}
+
+	if pos >= len(tokens) || tokens[pos].Type != RightBracketToken {
+		return attr, pos, false
+	}
+
+	return attr, pos + 1, true
+}
+
+func parsePseudoClass(tokens []Token, pos int) (PseudoClass, int, bool) {
+	if pos >= len(tokens) {
+		return PseudoClass{}, pos, false
+	}
+
+	tok := tokens[pos]
+	switch tok.Type {
+	case IdentToken:
+		return PseudoClass{Name: strings.ToLower(tok.Value)}, pos + 1, true
+
+	case FunctionToken:
+		pseudo := PseudoClass{Name: strings.ToLower(tok.Value)}
+		start := pos + 1
+		depth := 1
+		end := start
+		for ; end < len(tokens) && depth > 0; end++ {
+			switch tokens[end].Type {
+			case FunctionToken, LeftParenToken:
+				depth++
+			case RightParenToken:
+				depth--
+			}
+		}
+		if depth != 0 {
+			return pseudo, end, false
+		}
+		args := trimWhitespace(tokens[start : end-1])
+		pseudo.Arg = serialize(args)
+
+		switch pseudo.Name {
+		case "not":
+			not, next, ok := parseCompound(args, 0)
+			if !ok || next != len(args) {
+				return pseudo, end, false
+			}
+			pseudo.Not = not
+		case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+			a, b, ok := parseNth(pseudo.Arg)
+			if !ok {
+				return pseudo, end, false
+			}
+			pseudo.A, pseudo.B = a, b
+		}
+		return pseudo, end, true
+	}
+
+	return PseudoClass{}, pos, false
+}
+
+func parseNth(arg string) (int, int, bool) {
+	arg = strings.ToLower(strings.ReplaceAll(arg, " ", ""))
+
+	switch arg {
+	case "odd":
+		return 2, 1, true
+	case "even":
+		return 2, 0, true
+	}
+
+	n := strings.IndexByte(arg, 'n')
+	if n < 0 {
+		b, err := strconv.Atoi(arg)
+		return 0, b, err == nil
+	}
+
+	a := 1
+	switch prefix := arg[:n]; prefix {
+	case "", "+":
+	case "-":
+		a = -1
+	default:
+		var err error
+		if a, err = strconv.Atoi(prefix); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	b := 0
+	if rest := arg[n+1:]; rest != "" {
+		var err error
+		if b, err = strconv.Atoi(rest); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return a, b, true
+}
+
+func (s *Selector) Matches(node *html.Node) bool {
+	return s.matchFrom(node, len(s.Compounds)-1)
+}
+
+func (s *Selector) matchFrom(node *html.Node, index int) bool {
+	if !s.Compounds[index].matches(node) {
+		return false
+	}
+	if index == 0 {
+		return true
+	}
+
+	switch s.Combinators[index-1] {
+	case Child:
+		parent := parentElement(node)
+		return parent != nil && s.matchFrom(parent, index-1)
+
+	case Descendant:
+		for parent := parentElement(node); parent != nil; parent = parentElement(parent) {
+			if s.matchFrom(parent, index-1) {
+				return true
+			}
+		}
+
+	case NextSibling:
+		prev := previousElement(node)
+		return prev != nil && s.matchFrom(prev, index-1)
+
+	case SubsequentSibling:
+		for prev := previousElement(node); prev != nil; prev = previousElement(prev) {
+			if s.matchFrom(prev, index-1) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *Compound) matches(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+	if c.PseudoElement != "" {
+		return false
+	}
+	if c.Tag != "" && c.Tag != "*" && c.Tag != strings.ToLower(node.Data) {
+		return false
+	}
+	if c.ID != "" && attr(node, "id") != c.ID {
+		return false
+	}
+
+	if len(c.Classes) > 0 {
+		classes := strings.Fields(attr(node, "class"))
+		for _, class := range c.Classes {
+			if !contains(classes, class) {
+				return false
+			}
+		}
+	}
+
+	for _, a := range c.Attrs {
+		if !a.matches(node) {
+			return false
+		}
+	}
+
+	for _, pseudo := range c.Pseudos {
+		if !pseudo.matches(node) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a AttrSelector) matches(node *html.Node) bool {
+	value, ok := lookupAttr(node, a.Name)
+	if !ok {
+		return false
+	}
+
+	want := a.Value
+	if a.Flag == "i" || a.Flag == "" && caseInsensitiveAttrs[a.Name] {
+		value, want = asciiLower(value), asciiLower(want)
+	}
+
+	switch a.Operator {
+	case "":
+		return true
+	case "=":
+		return value == want
+	case "~=":
+		return contains(strings.Fields(value), want)
+	case "|=":
+		return value == want || strings.HasPrefix(value, want+"-")
+	case "^=":
+		return want != "" && strings.HasPrefix(value, want)
+	case "$=":
+		return want != "" && strings.HasSuffix(value, want)
+	case "*=":
+		return want != "" && strings.Contains(value, want)
+	}
+
+	return false
+}
+
+// caseInsensitiveAttrs are the HTML attributes whose values selectors match
+// ASCII case-insensitively, HTML "case-sensitivity of selectors".
+var caseInsensitiveAttrs = map[string]bool{
+	"accept": true, "accept-charset": true, "align": true, "alink": true, "axis": true,
+	"bgcolor": true, "charset": true, "checked": true, "clear": true, "codetype": true,
+	"color": true, "compact": true, "declare": true, "defer": true, "dir": true,
+	"direction": true, "disabled": true, "enctype": true, "face": true, "frame": true,
+	"hreflang": true, "http-equiv": true, "lang": true, "language": true, "link": true,
+	"media": true, "method": true, "multiple": true, "nohref": true, "noresize": true,
+	"noshade": true, "nowrap": true, "readonly": true, "rel": true, "rev": true,
+	"rules": true, "scope": true, "scrolling": true, "selected": true, "shape": true,
+	"target": true, "text": true, "type": true, "valign": true, "valuetype": true,
+	"vlink": true,
+}
+
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+func (p PseudoClass) matches(node *html.Node) bool {
+	switch p.Name {
+	case "root":
+		return parentElement(node) == nil
+	case "link", "any-link":
+		_, ok := lookupAttr(node, "href")
+		tag := strings.ToLower(node.Data)
+		return ok && (tag == "a" || tag == "area")
+	case "first-child":
+		return previousElement(node) == nil
+	case "last-child":
+		return nextElement(node) == nil
+	case "only-child":
+		return previousElement(node) == nil && nextElement(node) == nil
+	case "first-of-type":
+		return elementIndex(node, true, false) == 1
+	case "last-of-type":
+		return elementIndex(node, true, true) == 1
+	case "only-of-type":
+		return elementIndex(node, true, false) == 1 && elementIndex(node, true, true) == 1
+	case "empty":
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode || (child.Type == html.TextNode && child.Data != "") {
+				return false
+			}
+		}
+		return true
+	case "checked":
+		_, checked := lookupAttr(node, "checked")
+		_, selected := lookupAttr(node, "selected")
+		return checked || selected
+	case "disabled":
+		_, ok := lookupAttr(node, "disabled")
+		return ok
+	case "enabled":
+		_, ok := lookupAttr(node, "disabled")
+		return !ok
+	case "not":
+		return p.Not != nil && !p.Not.matches(node)
+	case "nth-child":
+		return nthMatches(p.A, p.B, elementIndex(node, false, false))
+	case "nth-last-child":
+		return nthMatches(p.A, p.B, elementIndex(node, false, true))
+	case "nth-of-type":
+		return nthMatches(p.A, p.B, elementIndex(node, true, false))
+	case "nth-last-of-type":
+		return nthMatches(p.A, p.B, elementIndex(node, true, true))
+	}
+
+	// Dynamic states (:hover, :focus, :visited, ...) never match.
+	return false
+}
+
+func nthMatches(a, b, index int) bool {
+	if a == 0 {
+		return index == b
+	}
+	n := index - b
+	return n%a == 0 && n/a >= 0
+}
+
+func elementIndex(node *html.Node, sameType, fromEnd bool) int {
+	index := 1
+	step := func(n *html.Node) *html.Node {
+		if fromEnd {
+			return nextElement(n)
+		}
+		return previousElement(n)
+	}
+
+	for sibling := step(node); sibling != nil; sibling = step(sibling) {
+		if !sameType || strings.EqualFold(sibling.Data, node.Data) {
+			index++
+		}
+	}
+	return index
+}
+
+func parentElement(node *html.Node) *html.Node {
+	if node.Parent != nil && node.Parent.Type == html.ElementNode {
+		return node.Parent
+	}
+	return nil
+}
+
+func previousElement(node *html.Node) *html.Node {
+	for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == html.ElementNode {
+			return sibling
+		}
+	}
+	return nil
+}
+
+func nextElement(node *html.Node) *html.Node {
+	for sibling := node.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode {
+			return sibling
+		}
+	}
+	return nil
+}
+
+func lookupAttr(node *html.Node, name string) (string, bool) {
+	for _, a := range node.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, name) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(node *html.Node, name string) string {
+	value, _ := lookupAttr(node, name)
+	return value
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
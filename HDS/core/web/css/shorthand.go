@@ -0,0 +1,158 @@
+package css
+
+import "strings"
+
+var sides = [4]string{"top", "right", "bottom", "left"}
+
+func expandShorthand(decl Declaration) []Declaration {
+	parts := SplitValues(decl.Value)
+
+	longhand := func(property, value string) Declaration {
+		return Declaration{Property: property, Value: value, Important: decl.Important}
+	}
+
+	switch decl.Property {
+	case "margin", "padding":
+		return expandBox(decl, decl.Property+"-%s", parts)
+
+	case "border-width", "border-style", "border-color":
+		kind := strings.TrimPrefix(decl.Property, "border-")
+		return expandBox(decl, "border-%s-"+kind, parts)
+
+	case "border", "border-top", "border-right", "border-bottom", "border-left":
+		targets := sides[:]
+		if decl.Property != "border" {
+			targets = []string{strings.TrimPrefix(decl.Property, "border-")}
+		}
+
+		width, style, color := "medium", "none", "currentcolor"
+		for _, part := range parts {
+			switch {
+			case isBorderStyle(part):
+				style = part
+			case IsLength(part) || part == "thin" || part == "medium" || part == "thick":
+				width = part
+			default:
+				color = part
+			}
+		}
+
+		var out []Declaration
+		for _, side := range targets {
+			out = append(out,
+				longhand("border-"+side+"-width", width),
+				longhand("border-"+side+"-style", style),
+				longhand("border-"+side+"-color", color),
+			)
+		}
+		return out
+
+	case "background":
+		color := "transparent"
+		for _, part := range parts {
+			if _, ok := ParseColor(part); ok {
+				color = part
+			}
+		}
+		return []Declaration{longhand("background-color", color)}
+
+	case "font":
+		style, weight, size, lineHeight := "normal", "normal", "medium", "normal"
+		for i, part := range parts {
+			switch {
+			case part == "italic" || part == "oblique":
+				style = part
+			case part == "bold" || part == "bolder" || part == "lighter" || isFontWeightNumber(part):
+				weight = part
+			case part == "normal" || part == "small-caps":
+			default:
+				size = part
+				if slash := strings.IndexByte(part, '/'); slash >= 0 {
+					size, lineHeight = part[:slash], part[slash+1:]
+				} else if i+2 < len(parts) && parts[i+1] == "/" {
+					lineHeight = parts[i+2]
+					parts = append(parts[:i+1], parts[i+3:]...)
+				}
+				return []Declaration{
+					longhand("font-style", style),
+					longhand("font-weight", weight),
+					longhand("font-size", size),
+					longhand("line-height", lineHeight),
+					longhand("font-family", strings.Join(parts[i+1:], " ")),
+				}
+			}
+		}
+		// system fonts (caption, menu, ...) are not supported
+		return nil
+	}
+
+	return []Declaration{decl}
+}
+
+func expandBox(decl Declaration, pattern string, parts []string) []Declaration {
+	var values [4]string
+	switch len(parts) {
+	case 1:
+		values = [4]string{parts[0], parts[0], parts[0], parts[0]}
+	case 2:
+		values = [4]string{parts[0], parts[1], parts[0], parts[1]}
+	case 3:
+		values = [4]string{parts[0], parts[1], parts[2], parts[1]}
+	case 4:
+		values = [4]string{parts[0], parts[1], parts[2], parts[3]}
+	default:
+		return nil
+	}
+
+	out := make([]Declaration, 0, 4)
+	for i, side := range sides {
+		out = append(out, Declaration{
+			Property:  strings.Replace(pattern, "%s", side, 1),
+			Value:     values[i],
+			Important: decl.Important,
+		})
+	}
+	return out
+}
+
+func isFontWeightNumber(value string) bool {
+	return len(value) == 3 && value[1:] == "00" && value[0] >= '1' && value[0] <= '9'
+}
+
+func isBorderStyle(value string) bool {
+	switch value {
+	case "none", "hidden", "dotted", "dashed", "solid", "double", "groove", "ridge", "inset", "outset":
+		return true
+	}
+	return false
+}
+
+// SplitValues splits a declaration value on whitespace outside of functions.
+func SplitValues(value string) []string {
+	var parts []string
+	depth := 0
+	start := -1
+
+	for i, r := range value {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case isWhitespace(r) && depth == 0:
+			if start >= 0 {
+				parts = append(parts, value[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		parts = append(parts, value[start:])
+	}
+
+	return parts
+}
@@ -0,0 +1,365 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type TokenType int
+
+const (
+	EOFToken TokenType = iota
+	IdentToken
+	FunctionToken
+	AtKeywordToken
+	HashToken
+	StringToken
+	URLToken
+	NumberToken
+	PercentageToken
+	DimensionToken
+	WhitespaceToken
+	ColonToken
+	SemicolonToken
+	CommaToken
+	LeftBraceToken
+	RightBraceToken
+	LeftBracketToken
+	RightBracketToken
+	LeftParenToken
+	RightParenToken
+	DelimToken
+)
+
+type Token struct {
+	Type  TokenType
+	Value string
+	Num   float64
+	Unit  string
+	Raw   string
+}
+
+type tokenizer struct {
+	src string
+	pos int
+}
+
+func Tokenize(src string) []Token {
+	t := &tokenizer{src: src}
+
+	var tokens []Token
+	for {
+		tok := t.next()
+		if tok.Type == EOFToken {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func (t *tokenizer) peek(offset int) rune {
+	pos := t.pos
+	for i := 0; i < offset; i++ {
+		if pos >= len(t.src) {
+			return 0
+		}
+		_, size := utf8.DecodeRuneInString(t.src[pos:])
+		pos += size
+	}
+	if pos >= len(t.src) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(t.src[pos:])
+	return r
+}
+
+func (t *tokenizer) advance() rune {
+	r, size := utf8.DecodeRuneInString(t.src[t.pos:])
+	t.pos += size
+	return r
+}
+
+func (t *tokenizer) next() Token {
+	t.skipComments()
+
+	start := t.pos
+	tok := t.consume()
+	tok.Raw = t.src[start:t.pos]
+
+	return tok
+}
+
+func (t *tokenizer) skipComments() {
+	for strings.HasPrefix(t.src[t.pos:], "/*") {
+		end := strings.Index(t.src[t.pos+2:], "*/")
+		if end < 0 {
+			t.pos = len(t.src)
+			return
+		}
+		t.pos += end + 4
+	}
+}
+
+func (t *tokenizer) consume() Token {
+	if t.pos >= len(t.src) {
+		return Token{Type: EOFToken}
+	}
+
+	r := t.peek(0)
+
+	switch {
+	case isWhitespace(r):
+		for t.pos < len(t.src) && isWhitespace(t.peek(0)) {
+			t.advance()
+		}
+		return Token{Type: WhitespaceToken, Value: " "}
+
+	case r == '"' || r == '\'':
+		t.advance()
+		return Token{Type: StringToken, Value: t.consumeString(r)}
+
+	case r == '#':
+		t.advance()
+		if isNameChar(t.peek(0)) || t.startsEscape() {
+			return Token{Type: HashToken, Value: t.consumeName()}
+		}
+		return Token{Type: DelimToken, Value: "#"}
+
+	case r == '@':
+		t.advance()
+		if t.startsIdent() {
+			return Token{Type: AtKeywordToken, Value: t.consumeName()}
+		}
+		return Token{Type: DelimToken, Value: "@"}
+
+	case t.startsNumber():
+		return t.consumeNumeric()
+
+	case t.startsIdent():
+		return t.consumeIdentLike()
+
+	case strings.HasPrefix(t.src[t.pos:], "<!--"):
+		t.pos += 4
+		return Token{Type: WhitespaceToken, Value: " "}
+
+	case strings.HasPrefix(t.src[t.pos:], "-->"):
+		t.pos += 3
+		return Token{Type: WhitespaceToken, Value: " "}
+	}
+
+	t.advance()
+	switch r {
+	case ':':
+		return Token{Type: ColonToken, Value: ":"}
+	case ';':
+		return Token{Type: SemicolonToken, Value: ";"}
+	case ',':
+		return Token{Type: CommaToken, Value: ","}
+	case '{':
+		return Token{Type: LeftBraceToken, Value: "{"}
+	case '}':
+		return Token{Type: RightBraceToken, Value: "}"}
+	case '[':
+		return Token{Type: LeftBracketToken, Value: "["}
+	case ']':
+		return Token{Type: RightBracketToken, Value: "]"}
+	case '(':
+		return Token{Type: LeftParenToken, Value: "("}
+	case ')':
+		return Token{Type: RightParenToken, Value: ")"}
+	}
+
+	return Token{Type: DelimToken, Value: string(r)}
+}
+
+func (t *tokenizer) consumeString(quote rune) string {
+	var sb strings.Builder
+
+	for t.pos < len(t.src) {
+		r := t.advance()
+		switch {
+		case r == quote:
+			return sb.String()
+		case r == '\n':
+			return sb.String()
+		case r == '\\':
+			if t.pos >= len(t.src) {
+				return sb.String()
+			}
+			if t.peek(0) == '\n' {
+				t.advance()
+				continue
+			}
+			sb.WriteRune(t.consumeEscape())
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+func (t *tokenizer) consumeEscape() rune {
+	r := t.advance()
+	if !isHexDigit(r) {
+		return r
+	}
+
+	hex := string(r)
+	for len(hex) < 6 && isHexDigit(t.peek(0)) {
+		hex += string(t.advance())
+	}
+	if isWhitespace(t.peek(0)) {
+		t.advance()
+	}
+
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || code == 0 || code > utf8.MaxRune {
+		return utf8.RuneError
+	}
+
+	return rune(code)
+}
+
+func (t *tokenizer) startsEscape() bool {
+	return t.peek(0) == '\\' && t.peek(1) != '\n' && t.peek(1) != 0
+}
+
+func (t *tokenizer) startsIdent() bool {
+	r := t.peek(0)
+	switch {
+	case r == '-':
+		next := t.peek(1)
+		return isNameStart(next) || next == '-' || (next == '\\' && t.peek(2) != '\n')
+	case r == '\\':
+		return t.startsEscape()
+	}
+	return isNameStart(r)
+}
+
+func (t *tokenizer) startsNumber() bool {
+	r := t.peek(0)
+	switch {
+	case r == '+' || r == '-':
+		next := t.peek(1)
+		return isDigit(next) || (next == '.' && isDigit(t.peek(2)))
+	case r == '.':
+		return isDigit(t.peek(1))
+	}
+	return isDigit(r)
+}
+
+func (t *tokenizer) consumeName() string {
+	var sb strings.Builder
+
+	for t.pos < len(t.src) {
+		switch {
+		case isNameChar(t.peek(0)):
+			sb.WriteRune(t.advance())
+		case t.startsEscape():
+			t.advance()
+			sb.WriteRune(t.consumeEscape())
+		default:
+			return sb.String()
+		}
+	}
+
+	return sb.String()
+}
+
+func (t *tokenizer) consumeNumeric() Token {
+	start := t.pos
+
+	if r := t.peek(0); r == '+' || r == '-' {
+		t.advance()
+	}
+	for isDigit(t.peek(0)) {
+		t.advance()
+	}
+	if t.peek(0) == '.' && isDigit(t.peek(1)) {
+		t.advance()
+		for isDigit(t.peek(0)) {
+			t.advance()
+		}
+	}
+	if r := t.peek(0); r == 'e' || r == 'E' {
+		next := t.peek(1)
+		if isDigit(next) || ((next == '+' || next == '-') && isDigit(t.peek(2))) {
+			t.advance()
+			t.advance()
+			for isDigit(t.peek(0)) {
+				t.advance()
+			}
+		}
+	}
+
+	num, _ := strconv.ParseFloat(t.src[start:t.pos], 64)
+	value := t.src[start:t.pos]
+
+	switch {
+	case t.startsIdent():
+		unit := t.consumeName()
+		return Token{Type: DimensionToken, Value: value, Num: num, Unit: strings.ToLower(unit)}
+	case t.peek(0) == '%':
+		t.advance()
+		return Token{Type: PercentageToken, Value: value, Num: num, Unit: "%"}
+	}
+
+	return Token{Type: NumberToken, Value: value, Num: num}
+}
+
+func (t *tokenizer) consumeIdentLike() Token {
+	name := t.consumeName()
+
+	if t.peek(0) != '(' {
+		return Token{Type: IdentToken, Value: name}
+	}
+	t.advance()
+
+	if !strings.EqualFold(name, "url") {
+		return Token{Type: FunctionToken, Value: name}
+	}
+
+	for isWhitespace(t.peek(0)) {
+		t.advance()
+	}
+	if r := t.peek(0); r == '"' || r == '\'' {
+		return Token{Type: FunctionToken, Value: name}
+	}
+
+	var sb strings.Builder
+	for t.pos < len(t.src) {
+		r := t.advance()
+		switch {
+		case r == ')':
+			return Token{Type: URLToken, Value: strings.TrimSpace(sb.String())}
+		case r == '\\' && t.pos < len(t.src):
+			sb.WriteRune(t.consumeEscape())
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return Token{Type: URLToken, Value: strings.TrimSpace(sb.String())}
+}
+
+func isWhitespace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDigit(r) || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func isNameStart(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || r >= 0x80
+}
+
+func isNameChar(r rune) bool {
+	return isNameStart(r) || isDigit(r) || r == '-'
+}
@@ -0,0 +1,277 @@
+package css
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Length struct {
+	Value float32
+	Unit  string
+}
+
+func ParseLength(value string) (Length, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "0" {
+		return Length{}, true
+	}
+
+	tokens := Tokenize(value)
+	if len(tokens) != 1 {
+		return Length{}, false
+	}
+
+	switch tok := tokens[0]; tok.Type {
+	case DimensionToken, PercentageToken:
+		return Length{Value: float32(tok.Num), Unit: tok.Unit}, true
+	case NumberToken:
+		return Length{Value: float32(tok.Num)}, true
+	}
+
+	return Length{}, false
+}
+
+// ToPx converts a length to CSS pixels. em and ex are relative to fontSize,
+// rem to rootSize and percentages to percentBase.
+func ToPx(value string, fontSize, rootSize, percentBase float32, media Media) (float32, bool) {
+	length, ok := ParseLength(value)
+	if !ok {
+		return 0, false
+	}
+
+	v := length.Value
+	switch length.Unit {
+	case "px":
+		return v, true
+	case "", "%":
+		if length.Unit == "" && v != 0 {
+			return 0, false
+		}
+		return v * percentBase / 100, true
+	case "em":
+		return v * fontSize, true
+	case "rem":
+		return v * rootSize, true
+	case "ex", "ch":
+		return v * fontSize / 2, true
+	case "pt":
+		return v * 4 / 3, true
+	case "pc":
+		return v * 16, true
+	case "in":
+		return v * 96, true
+	case "cm":
+		return v * 96 / 2.54, true
+	case "mm":
+		return v * 96 / 25.4, true
+	case "q":
+		return v * 96 / 101.6, true
+	case "vw":
+		return v * media.Width / 100, true
+	case "vh":
+		return v * media.Height / 100, true
+	case "vmin":
+		return v * min(media.Width, media.Height) / 100, true
+	case "vmax":
+		return v * max(media.Width, media.Height) / 100, true
+	}
+
+	return 0, false
+}
+
+func IsLength(value string) bool {
+	length, ok := ParseLength(value)
+	return ok && (length.Unit != "" || length.Value == 0)
+}
+
+func ParseColor(value string) ([3]float32, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if hex, ok := namedColors[value]; ok {
+		value = hex
+	}
+
+	if strings.HasPrefix(value, "#") {
+		return parseHexColor(value[1:])
+	}
+
+	open := strings.IndexByte(value, '(')
+	if open < 0 || !strings.HasSuffix(value, ")") {
+		return [3]float32{}, false
+	}
+
+	name := value[:open]
+	args := strings.FieldsFunc(value[open+1:len(value)-1], func(r rune) bool {
+		return r == ',' || r == '/' || isWhitespace(r)
+	})
+	if len(args) < 3 {
+		return [3]float32{}, false
+	}
+
+	switch name {
+	case "rgb", "rgba":
+		var rgb [3]float32
+		for i := 0; i < 3; i++ {
+			channel, ok := parseChannel(args[i], 255)
+			if !ok {
+				return [3]float32{}, false
+			}
+			rgb[i] = channel
+		}
+		return rgb, true
+
+	case "hsl", "hsla":
+		hue, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+		if err != nil {
+			return [3]float32{}, false
+		}
+		saturation, ok1 := parseChannel(args[1], 100)
+		lightness, ok2 := parseChannel(args[2], 100)
+		if !ok1 || !ok2 {
+			return [3]float32{}, false
+		}
+		return hslToRGB(hue, float64(saturation), float64(lightness)), true
+	}
+
+	return [3]float32{}, false
+}
+
+// IsTransparent reports whether a color value paints nothing.
+func IsTransparent(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "transparent" {
+		return true
+	}
+
+	if strings.HasPrefix(value, "#") {
+		switch hex := value[1:]; len(hex) {
+		case 4:
+			return hex[3] == '0'
+		case 8:
+			return hex[6:] == "00"
+		}
+		return false
+	}
+
+	open := strings.IndexByte(value, '(')
+	if open < 0 || !strings.HasSuffix(value, ")") {
+		return false
+	}
+	args := strings.FieldsFunc(value[open+1:len(value)-1], func(r rune) bool {
+		return r == ',' || r == '/' || isWhitespace(r)
+	})
+	if len(args) == 4 {
+		alpha, ok := parseChannel(args[3], 1)
+		return ok && alpha == 0
+	}
+
+	return false
+}
+
+func parseChannel(value string, scale float64) (float32, bool) {
+	if strings.HasSuffix(value, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return 0, false
+		}
+		return float32(math.Max(0, math.Min(1, f/100))), true
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return float32(math.Max(0, math.Min(1, f/scale))), true
+}
+
+func parseHexColor(hex string) ([3]float32, bool) {
+	switch len(hex) {
+	case 3, 4:
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	case 6, 8:
+		hex = hex[:6]
+	default:
+		return [3]float32{}, false
+	}
+
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return [3]float32{}, false
+	}
+
+	return [3]float32{
+		float32(n>>16&0xff) / 255.0,
+		float32(n>>8&0xff) / 255.0,
+		float32(n&0xff) / 255.0,
+	}, true
+}
+
+func hslToRGB(h, s, l float64) [3]float32 {
+	h = math.Mod(math.Mod(h, 360)+360, 360) / 360
+
+	if s == 0 {
+		return [3]float32{float32(l), float32(l), float32(l)}
+	}
+
+	q := l + s - l*s
+	if l < 0.5 {
+		q = l * (1 + s)
+	}
+	p := 2*l - q
+
+	hue := func(t float64) float32 {
+		t = math.Mod(t+1, 1)
+		switch {
+		case t < 1.0/6:
+			return float32(p + (q-p)*6*t)
+		case t < 0.5:
+			return float32(q)
+		case t < 2.0/3:
+			return float32(p + (q-p)*(2.0/3-t)*6)
+		}
+		return float32(p)
+	}
+
+	return [3]float32{hue(h + 1.0/3), hue(h), hue(h - 1.0/3)}
+}
+
+var namedColors = map[string]string{
+	"aliceblue": "#f0f8ff", "antiquewhite": "#faebd7", "aqua": "#00ffff", "aquamarine": "#7fffd4",
+	"azure": "#f0ffff", "beige": "#f5f5dc", "bisque": "#ffe4c4", "black": "#000000",
+	"blanchedalmond": "#ffebcd", "blue": "#0000ff", "blueviolet": "#8a2be2", "brown": "#a52a2a",
+	"burlywood": "#deb887", "cadetblue": "#5f9ea0", "chartreuse": "#7fff00", "chocolate": "#d2691e",
+	"coral": "#ff7f50", "cornflowerblue": "#6495ed", "cornsilk": "#fff8dc", "crimson": "#dc143c",
+	"cyan": "#00ffff", "darkblue": "#00008b", "darkcyan": "#008b8b", "darkgoldenrod": "#b8860b",
+	"darkgray": "#a9a9a9", "darkgreen": "#006400", "darkgrey": "#a9a9a9", "darkkhaki": "#bdb76b",
+	"darkmagenta": "#8b008b", "darkolivegreen": "#556b2f", "darkorange": "#ff8c00", "darkorchid": "#9932cc",
+	"darkred": "#8b0000", "darksalmon": "#e9967a", "darkseagreen": "#8fbc8f", "darkslateblue": "#483d8b",
+	"darkslategray": "#2f4f4f", "darkslategrey": "#2f4f4f", "darkturquoise": "#00ced1", "darkviolet": "#9400d3",
+	"deeppink": "#ff1493", "deepskyblue": "#00bfff", "dimgray": "#696969", "dimgrey": "#696969",
+	"dodgerblue": "#1e90ff", "firebrick": "#b22222", "floralwhite": "#fffaf0", "forestgreen": "#228b22",
+	"fuchsia": "#ff00ff", "gainsboro": "#dcdcdc", "ghostwhite": "#f8f8ff", "gold": "#ffd700",
+	"goldenrod": "#daa520", "gray": "#808080", "green": "#008000", "greenyellow": "#adff2f",
+	"grey": "#808080", "honeydew": "#f0fff0", "hotpink": "#ff69b4", "indianred": "#cd5c5c",
+	"indigo": "#4b0082", "ivory": "#fffff0", "khaki": "#f0e68c", "lavender": "#e6e6fa",
+	"lavenderblush": "#fff0f5", "lawngreen": "#7cfc00", "lemonchiffon": "#fffacd", "lightblue": "#add8e6",
+	"lightcoral": "#f08080", "lightcyan": "#e0ffff", "lightgoldenrodyellow": "#fafad2", "lightgray": "#d3d3d3",
+	"lightgreen": "#90ee90", "lightgrey": "#d3d3d3", "lightpink": "#ffb6c1", "lightsalmon": "#ffa07a",
+	"lightseagreen": "#20b2aa", "lightskyblue": "#87cefa", "lightslategray": "#778899", "lightslategrey": "#778899",
+	"lightsteelblue": "#b0c4de", "lightyellow": "#ffffe0", "lime": "#00ff00", "limegreen": "#32cd32",
+	"linen": "#faf0e6", "magenta": "#ff00ff", "maroon": "#800000", "mediumaquamarine": "#66cdaa",
+	"mediumblue": "#0000cd", "mediumorchid": "#ba55d3", "mediumpurple": "#9370db", "mediumseagreen": "#3cb371",
+	"mediumslateblue": "#7b68ee", "mediumspringgreen": "#00fa9a", "mediumturquoise": "#48d1cc", "mediumvioletred": "#c71585",
+	"midnightblue": "#191970", "mintcream": "#f5fffa", "mistyrose": "#ffe4e1", "moccasin": "#ffe4b5",
+	"navajowhite": "#ffdead", "navy": "#000080", "oldlace": "#fdf5e6", "olive": "#808000",
+	"olivedrab": "#6b8e23", "orange": "#ffa500", "orangered": "#ff4500", "orchid": "#da70d6",
+	"palegoldenrod": "#eee8aa", "palegreen": "#98fb98", "paleturquoise": "#afeeee", "palevioletred": "#db7093",
+	"papayawhip": "#ffefd5", "peachpuff": "#ffdab9", "peru": "#cd853f", "pink": "#ffc0cb",
+	"plum": "#dda0dd", "powderblue": "#b0e0e6", "purple": "#800080", "rebeccapurple": "#663399",
+	"red": "#ff0000", "rosybrown": "#bc8f8f", "royalblue": "#4169e1", "saddlebrown": "#8b4513",
+	"salmon": "#fa8072", "sandybrown": "#f4a460", "seagreen": "#2e8b57", "seashell": "#fff5ee",
+	"sienna": "#a0522d", "silver": "#c0c0c0", "skyblue": "#87ceeb", "slateblue": "#6a5acd",
+	"slategray": "#708090", "slategrey": "#708090", "snow": "#fffafa", "springgreen": "#00ff7f",
+	"steelblue": "#4682b4", "tan": "#d2b48c", "teal": "#008080", "thistle": "#d8bfd8",
+	"tomato": "#ff6347", "turquoise": "#40e0d0", "violet": "#ee82ee", "wheat": "#f5deb3",
+	"white": "#ffffff", "whitesmoke": "#f5f5f5", "yellow": "#ffff00", "yellowgreen": "#9acd32",
+}
@@ -0,0 +1,782 @@
+package html
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	drawer "github.com/RDLxxx/Himera/HGD/Draw/Drawer"
+	"github.com/RDLxxx/Himera/HGD/Draw/InputLIB"
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+	"golang.org/x/net/html"
+)
+
+type ControlKind int
+
+const (
+	TextControl ControlKind = iota
+	PasswordControl
+	CheckboxControl
+	RadioControl
+	ButtonControl
+	SelectControl
+	TextAreaControl
+)
+
+type SelectOption struct {
+	Label    string
+	Value    string
+	Disabled bool
+}
+
+// Control is the state of a form widget. It is kept per element, so it
+// survives the layout being rebuilt.
+type Control struct {
+	Kind ControlKind
+	Node *html.Node
+
+	// Type is the lower case type of an <input> or <button>.
+	Type string
+
+	// Value is the text of a field or the label of a button, Cursor a byte
+	// offset into it.
+	Value  string
+	Cursor int
+
+	Checked  bool
+	Options  []SelectOption
+	Selected int
+
+	Disabled bool
+	ReadOnly bool
+
+	box    *LayoutBox
+	scroll int
+}
+
+var (
+	controlBackground = [3]float32{0.18, 0.18, 0.18}
+	buttonBackground  = [3]float32{0.3, 0.3, 0.3}
+	controlBorder     = [3]float32{0.5, 0.5, 0.5}
+	controlFocus      = [3]float32{0.39, 0.58, 0.93}
+	placeholderColor  = [3]float32{0.55, 0.55, 0.55}
+)
+
+// Editable reports whether typing goes into the control.
+func (c *Control) Editable() bool {
+	switch c.Kind {
+	case TextControl, PasswordControl, TextAreaControl:
+		return !c.Disabled && !c.ReadOnly
+	}
+	return false
+}
+
+// newControl reads the initial state of a form element, nil for elements
+// that are not drawn.
+func newControl(node *html.Node) *Control {
+	c := &Control{
+		Node:     node,
+		Type:     strings.ToLower(getAttr(node, "type")),
+		Disabled: hasAttr(node, "disabled"),
+		ReadOnly: hasAttr(node, "readonly"),
+	}
+
+	switch strings.ToLower(node.Data) {
+	case "input":
+		switch c.Type {
+		case "hidden":
+			return nil
+		case "password":
+			c.Kind = PasswordControl
+			c.Value = getAttr(node, "value")
+		case "checkbox", "radio":
+			c.Kind = CheckboxControl
+			if c.Type == "radio" {
+				c.Kind = RadioControl
+			}
+			c.Checked = hasAttr(node, "checked")
+		case "submit", "reset", "button", "image", "file":
+			c.Kind = ButtonControl
+			c.Value = getAttr(node, "value")
+			if c.Value == "" && !hasAttr(node, "value") {
+				c.Value = map[string]string{"submit": "Submit", "reset": "Reset", "image": getAttr(node, "alt"), "file": "Choose file"}[c.Type]
+			}
+		default:
+			c.Kind = TextControl
+			c.Value = getAttr(node, "value")
+		}
+
+	case "button":
+		c.Kind = ButtonControl
+		if c.Type == "" {
+			c.Type = "submit"
+		}
+		c.Value = strings.Join(strings.Fields(textContent(node)), " ")
+
+	case "select":
+		c.Kind = SelectControl
+		c.Selected = -1
+		var walk func(*html.Node, bool)
+		walk = func(n *html.Node, disabled bool) {
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				if child.Type != html.ElementNode {
+					continue
+				}
+				switch strings.ToLower(child.Data) {
+				case "optgroup":
+					walk(child, disabled || hasAttr(child, "disabled"))
+				case "option":
+					label := strings.Join(strings.Fields(textContent(child)), " ")
+					value := label
+					if hasAttr(child, "value") {
+						value = getAttr(child, "value")
+					}
+					if hasAttr(child, "selected") {
+						c.Selected = len(c.Options)
+					}
+					c.Options = append(c.Options, SelectOption{Label: label, Value: value, Disabled: disabled || hasAttr(child, "disabled")})
+				}
+			}
+		}
+		walk(node, false)
+		if c.Selected < 0 {
+			c.Selected = c.nextOption(-1, 1)
+		}
+
+	case "textarea":
+		c.Kind = TextAreaControl
+		c.Value = textContent(node)
+
+	default:
+		return nil
+	}
+
+	c.Cursor = len(c.Value)
+	return c
+}
+
+func textContent(node *html.Node) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return text.String()
+}
+
+// buildControl makes box the atomic box of a form control, it reports false
+// for elements that generate no box.
+func (r *HTMLRenderer) buildControl(box *LayoutBox) bool {
+	c := r.controls[box.Node]
+	if c == nil {
+		if c = newControl(box.Node); c == nil {
+			return false
+		}
+		r.controls[box.Node] = c
+	}
+
+	c.box = box
+	box.Control = c
+	r.controlOrder = append(r.controlOrder, c)
+	return true
+}
+
+func isControlElement(node *html.Node) bool {
+	switch strings.ToLower(node.Data) {
+	case "input", "button", "select", "textarea":
+		return true
+	}
+	return false
+}
+
+// controlSize is the used size of a control box in zoomed pixels.
+func (r *HTMLRenderer) controlSize(ctx *RenderContext, box *LayoutBox) (float32, float32) {
+	c := box.Control
+	style := box.Style
+	scale := style.FontScale * ctx.Zoom
+	face := TextLIB.GetFace(style.Font)
+	edge := (controlPadding + 1) * ctx.Zoom * 2
+	lineHeight := face.LineHeight(scale)
+	charWidth := r.measureText(style, "0", scale)
+
+	var width, height float32
+	switch c.Kind {
+	case CheckboxControl, RadioControl:
+		width = face.Ascent(scale)
+		height = width
+	case ButtonControl:
+		width = r.measureText(style, c.Value, scale) + edge + 8*ctx.Zoom
+		height = lineHeight + edge
+	case SelectControl:
+		for _, option := range c.Options {
+			width = max(width, r.measureText(style, option.Label, scale))
+		}
+		width += edge + selectArrowWidth*ctx.Zoom
+		height = lineHeight + edge
+	case TextAreaControl:
+		width = float32(intAttr(c.Node, "cols", 20))*charWidth + edge
+		height = float32(intAttr(c.Node, "rows", 2))*lineHeight + edge
+	default:
+		width = float32(intAttr(c.Node, "size", 20))*charWidth + edge
+		height = lineHeight + edge
+	}
+
+	if w, h, _ := r.specifiedImageSize(box); w >= 0 || h >= 0 {
+		if w >= 0 {
+			width = w * ctx.Zoom
+		}
+		if h >= 0 {
+			height = h * ctx.Zoom
+		}
+	}
+	return width, height
+}
+
+const (
+	controlPadding   = 4
+	selectArrowWidth = 16
+)
+
+func intAttr(node *html.Node, name string, fallback int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(getAttr(node, name))); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
+
+// FocusedControl is the control keyboard input goes to, nil when none has
+// focus.
+func (r *HTMLRenderer) FocusedControl() *Control {
+	return r.focused
+}
+
+// Blur takes the focus away from the page's controls.
+func (r *HTMLRenderer) Blur() {
+	r.focused = nil
+	r.openSelect = nil
+}
+
+// FocusNext moves the focus to the next control in document order, or the
+// previous one, wrapping around. It reports false when there is none.
+func (r *HTMLRenderer) FocusNext(backward bool) bool {
+	var focusable []*Control
+	for _, c := range r.controlOrder {
+		if !c.Disabled && c.box != nil && c.box.Style.Visible {
+			focusable = append(focusable, c)
+		}
+	}
+	if len(focusable) == 0 {
+		return false
+	}
+
+	index := -1
+	for i, c := range focusable {
+		if c == r.focused {
+			index = i
+		}
+	}
+
+	switch {
+	case index < 0 && backward:
+		index = len(focusable) - 1
+	case index < 0:
+		index = 0
+	case backward:
+		index = (index + len(focusable) - 1) % len(focusable)
+	default:
+		index = (index + 1) % len(focusable)
+	}
+
+	r.openSelect = nil
+	r.focused = focusable[index]
+	r.focused.Cursor = len(r.focused.Value)
+	return true
+}
+
+// FocusedRect is where the focused control was laid out.
+func (r *HTMLRenderer) FocusedRect() (Rect, bool) {
+	if r.focused == nil || r.focused.box == nil {
+		return Rect{}, false
+	}
+	return r.focused.box.Dimensions.BorderBox(), true
+}
+
+// ClickAt handles a click on the page's controls at the window point x, y
+// and moves the focus. It reports whether a control took the click.
+func (r *HTMLRenderer) ClickAt(ctx *RenderContext, x, y float32) bool {
+	if err := r.ensureParsed(); err != nil {
+		return false
+	}
+
+	open := r.openSelect
+	if index, ok := r.popupOptionAt(ctx, x, y); ok {
+		r.openSelect = nil
+		if !open.Options[index].Disabled {
+			open.Selected = index
+		}
+		return true
+	}
+	r.openSelect = nil
+
+	root := r.cachedLayout(ctx)
+	if root == nil {
+		return false
+	}
+
+	box := hitTest(root, x, y-ctx.ScrollOffset)
+	c := r.controlAt(box)
+	if c == nil || c.Disabled {
+		r.focused = nil
+		return c != nil
+	}
+
+	r.focused = c
+	switch c.Kind {
+	case CheckboxControl, RadioControl, ButtonControl:
+		r.activate(c)
+	case SelectControl:
+		if box.Control == c && open != c {
+			r.openSelect = c
+		}
+	case TextControl, PasswordControl, TextAreaControl:
+		if box.Control == c {
+			c.Cursor = r.cursorAt(ctx, c, x, y-ctx.ScrollOffset)
+		}
+	}
+	return true
+}
+
+// controlAt finds the control a click on box is for, clicking a <label>
+// counts for its control.
+func (r *HTMLRenderer) controlAt(box *LayoutBox) *Control {
+	if box == nil {
+		return nil
+	}
+	if box.Control != nil {
+		return box.Control
+	}
+
+	for node := box.Node; node != nil; node = node.Parent {
+		if node.Type != html.ElementNode || strings.ToLower(node.Data) != "label" {
+			continue
+		}
+
+		if id := getAttr(node, "for"); id != "" {
+			for _, c := range r.controlOrder {
+				if getAttr(c.Node, "id") == id {
+					return c
+				}
+			}
+			return nil
+		}
+		for _, c := range r.controlOrder {
+			if isAncestor(node, c.Node) {
+				return c
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func isAncestor(ancestor, node *html.Node) bool {
+	for n := node.Parent; n != nil; n = n.Parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// ActivateFocused is Space on the focused control: it toggles checkboxes,
+// opens selects and presses buttons.
+func (r *HTMLRenderer) ActivateFocused() bool {
+	if r.focused == nil || r.focused.Editable() {
+		return false
+	}
+	return r.activate(r.focused)
+}
+
+func (r *HTMLRenderer) activate(c *Control) bool {
+	switch c.Kind {
+	case CheckboxControl:
+		c.Checked = !c.Checked
+	case RadioControl:
+		r.checkRadio(c)
+	case SelectControl:
+		if r.openSelect == c {
+			r.openSelect = nil
+		} else {
+			r.openSelect = c
+		}
+	case ButtonControl:
+		return r.press(c)
+	default:
+		return false
+	}
+	return true
+}
+
+// checkRadio checks c and unchecks the other buttons of its group.
+func (r *HTMLRenderer) checkRadio(c *Control) {
+	name := getAttr(c.Node, "name")
+	form := r.formOf(c.Node)
+	for _, other := range r.controlOrder {
+		if other.Kind == RadioControl && name != "" && getAttr(other.Node, "name") == name && r.formOf(other.Node) == form {
+			other.Checked = false
+		}
+	}
+	c.Checked = true
+}
+
+// formOf is the form owner of a control, the form its form attribute
+// names or else the nearest <form> around it.
+func (r *HTMLRenderer) formOf(node *html.Node) *html.Node {
+	if id := getAttr(node, "form"); id != "" && r.cachedDoc != nil {
+		if form := findAnchor(r.cachedDoc, id); form != nil && strings.ToLower(form.Data) == "form" {
+			return form
+		}
+		return nil
+	}
+
+	for n := node.Parent; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode && strings.ToLower(n.Data) == "form" {
+			return n
+		}
+	}
+	return nil
+}
+
+// TypeText inserts text at the cursor of the focused field.
+func (r *HTMLRenderer) TypeText(text string) bool {
+	c := r.focused
+	if c == nil || !c.Editable() {
+		return false
+	}
+	if c.Kind != TextAreaControl {
+		text = strings.ReplaceAll(text, "\n", "")
+	}
+
+	c.Value, c.Cursor = InputLIB.Insert(c.Value, c.Cursor, text)
+	return true
+}
+
+// EditFocused applies an editing key to the focused control, the arrows
+// step through the options of a select.
+func (r *HTMLRenderer) EditFocused(key InputLIB.Key) bool {
+	c := r.focused
+	if c == nil {
+		return false
+	}
+
+	if c.Kind == SelectControl {
+		step := 0
+		switch key {
+		case InputLIB.KeyUp, InputLIB.KeyLeft:
+			step = -1
+		case InputLIB.KeyDown, InputLIB.KeyRight:
+			step = 1
+		}
+		if next := c.nextOption(c.Selected, step); step != 0 && next >= 0 {
+			c.Selected = next
+			return true
+		}
+		return false
+	}
+
+	if !c.Editable() {
+		return false
+	}
+
+	var changed bool
+	c.Value, c.Cursor, changed = InputLIB.Edit(c.Value, c.Cursor, key, c.Kind == TextAreaControl)
+	return changed
+}
+
+// nextOption is the first enabled option after from in direction step,
+// -1 when there is none.
+func (c *Control) nextOption(from, step int) int {
+	if step == 0 {
+		return -1
+	}
+	for i := from + step; i >= 0 && i < len(c.Options); i += step {
+		if !c.Options[i].Disabled {
+			return i
+		}
+	}
+	return -1
+}
+
+// display is the text a field shows, passwords are masked rune by rune.
+func (c *Control) display() string {
+	if c.Kind == PasswordControl {
+		return strings.Repeat("*", utf8.RuneCountInString(c.Value))
+	}
+	return c.Value
+}
+
+// displayOffset maps a byte offset of Value to one of display.
+func (c *Control) displayOffset(offset int) int {
+	if c.Kind == PasswordControl {
+		return utf8.RuneCountInString(c.Value[:offset])
+	}
+	return offset
+}
+
+func (c *Control) valueOffset(offset int) int {
+	if c.Kind != PasswordControl {
+		return offset
+	}
+	for i := range c.Value {
+		if offset == 0 {
+			return i
+		}
+		offset--
+	}
+	return len(c.Value)
+}
+
+func (r *HTMLRenderer) cursorAt(ctx *RenderContext, c *Control, x, y float32) int {
+	d := c.box.Dimensions
+	scale := c.box.Style.FontScale * ctx.Zoom
+	inset := (controlPadding + 1) * ctx.Zoom
+	measure := func(s string) float32 { return r.measureText(c.box.Style, s, scale) }
+
+	if c.Kind == TextAreaControl {
+		lineHeight := TextLIB.GetFace(c.box.Style.Font).LineHeight(scale)
+		row := c.scroll + int((y-d.Y-inset)/lineHeight)
+		start := 0
+		for ; row > 0; row-- {
+			next := strings.IndexByte(c.Value[start:], '\n')
+			if next < 0 {
+				break
+			}
+			start += next + 1
+		}
+		end := strings.IndexByte(c.Value[start:], '\n')
+		if end < 0 {
+			end = len(c.Value) - start
+		}
+		return start + InputLIB.CursorAt(c.Value[start:start+end], x-d.X-inset, measure)
+	}
+
+	text := c.display()
+	scroll := min(c.scroll, len(text))
+	offset := InputLIB.CursorAt(text[scroll:], x-d.X-inset, measure)
+	return c.valueOffset(scroll + offset)
+}
+
+func (r *HTMLRenderer) paintControl(ctx *RenderContext, box *LayoutBox) {
+	c := box.Control
+	d := box.Dimensions
+	x, y, w, h := d.X, d.Y+ctx.ScrollOffset, d.Width, d.Height
+	z := ctx.Zoom
+	style := box.Style
+	scale := style.FontScale * z
+	face := TextLIB.GetFace(style.Font)
+	inset := (controlPadding + 1) * z
+
+	color := style.Color
+	if c.Disabled {
+		color = placeholderColor
+	}
+	border := controlBorder
+	if c == r.focused {
+		border = controlFocus
+	}
+
+	frame := func(background [3]float32) {
+		drawer.DrawRect(ctx.RectProgram, x, y, w, h, border)
+		drawer.DrawRect(ctx.RectProgram, x+z, y+z, w-2*z, h-2*z, background)
+	}
+	baseline := y + inset + face.Ascent(scale)
+
+	switch c.Kind {
+	case CheckboxControl, RadioControl:
+		frame(controlBackground)
+		if c.Checked {
+			mark := w * 0.25
+			if c.Kind == RadioControl {
+				mark = w * 0.3
+			}
+			drawer.DrawRect(ctx.RectProgram, x+mark, y+mark, w-2*mark, h-2*mark, controlFocus)
+		}
+
+	case ButtonControl:
+		frame(buttonBackground)
+		label := r.fitText(style, c.Value, scale, w-2*inset)
+		labelX := x + (w-r.measureText(style, label, scale))/2
+		TextLIB.DrawTextFace(ctx.Program, face, label, labelX, y+(h-face.LineHeight(scale))/2+face.Ascent(scale), scale, color)
+
+	case SelectControl:
+		frame(controlBackground)
+		if c.Selected >= 0 && c.Selected < len(c.Options) {
+			label := r.fitText(style, c.Options[c.Selected].Label, scale, w-2*inset-selectArrowWidth*z)
+			TextLIB.DrawTextFace(ctx.Program, face, label, x+inset, baseline, scale, color)
+		}
+		TextLIB.DrawTextFace(ctx.Program, face, "v", x+w-inset-selectArrowWidth*z/2-r.measureText(style, "v", scale)/2, baseline, scale, color)
+
+	case TextAreaControl:
+		frame(controlBackground)
+		r.paintTextArea(ctx, box, color)
+
+	default:
+		frame(controlBackground)
+		inner := w - 2*inset
+		text := c.display()
+		cursor := c.displayOffset(min(c.Cursor, len(c.Value)))
+
+		// scroll so the cursor stays in view
+		c.scroll = min(c.scroll, cursor)
+		for c.scroll < cursor && r.measureText(style, text[c.scroll:cursor], scale) > inner {
+			_, size := utf8.DecodeRuneInString(text[c.scroll:])
+			c.scroll += size
+		}
+
+		if text == "" && c != r.focused {
+			if placeholder := getAttr(c.Node, "placeholder"); placeholder != "" {
+				TextLIB.DrawTextFace(ctx.Program, face, r.fitText(style, placeholder, scale, inner), x+inset, baseline, scale, placeholderColor)
+			}
+		}
+
+		visible := r.clipText(style, text[c.scroll:], scale, inner)
+		TextLIB.DrawTextFace(ctx.Program, face, visible, x+inset, baseline, scale, color)
+
+		if c == r.focused && c.Editable() {
+			caret := x + inset + r.measureText(style, text[c.scroll:cursor], scale)
+			drawer.DrawRect(ctx.RectProgram, caret, y+inset, max(z, 1), face.LineHeight(scale), color)
+		}
+	}
+}
+
+func (r *HTMLRenderer) paintTextArea(ctx *RenderContext, box *LayoutBox, color [3]float32) {
+	c := box.Control
+	d := box.Dimensions
+	z := ctx.Zoom
+	style := box.Style
+	scale := style.FontScale * z
+	face := TextLIB.GetFace(style.Font)
+	inset := (controlPadding + 1) * z
+	lineHeight := face.LineHeight(scale)
+	inner := d.Width - 2*inset
+
+	lines := strings.Split(c.Value, "\n")
+	rows := max(1, int((d.Height-2*inset)/lineHeight))
+
+	cursor := min(c.Cursor, len(c.Value))
+	cursorRow := strings.Count(c.Value[:cursor], "\n")
+	lineStart := strings.LastIndexByte(c.Value[:cursor], '\n') + 1
+
+	// scroll so the cursor line stays in view
+	c.scroll = max(0, min(c.scroll, cursorRow, len(lines)-1))
+	if cursorRow >= c.scroll+rows {
+		c.scroll = cursorRow - rows + 1
+	}
+
+	top := d.Y + ctx.ScrollOffset + inset
+	for row := c.scroll; row < len(lines) && row < c.scroll+rows; row++ {
+		baseline := top + float32(row-c.scroll)*lineHeight + face.Ascent(scale)
+		TextLIB.DrawTextFace(ctx.Program, face, r.clipText(style, lines[row], scale, inner), d.X+inset, baseline, scale, color)
+	}
+
+	if c == r.focused && c.Editable() {
+		caret := d.X + inset + r.measureText(style, c.Value[lineStart:cursor], scale)
+		if caret <= d.X+d.Width-inset {
+			drawer.DrawRect(ctx.RectProgram, caret, top+float32(cursorRow-c.scroll)*lineHeight, max(z, 1), lineHeight, color)
+		}
+	}
+}
+
+// paintSelectPopup draws the option list of the open select over the page.
+func (r *HTMLRenderer) paintSelectPopup(ctx *RenderContext) {
+	c := r.openSelect
+	if c == nil || c.box == nil {
+		return
+	}
+
+	rect, optionHeight := r.popupRect(ctx)
+	style := c.box.Style
+	scale := style.FontScale * ctx.Zoom
+	face := TextLIB.GetFace(style.Font)
+	inset := (controlPadding + 1) * ctx.Zoom
+	y := rect.Y + ctx.ScrollOffset
+
+	drawer.DrawRect(ctx.RectProgram, rect.X, y, rect.Width, rect.Height, controlFocus)
+	drawer.DrawRect(ctx.RectProgram, rect.X+ctx.Zoom, y+ctx.Zoom, rect.Width-2*ctx.Zoom, rect.Height-2*ctx.Zoom, controlBackground)
+
+	for i, option := range c.Options {
+		top := y + float32(i)*optionHeight
+		color := style.Color
+		if i == c.Selected {
+			drawer.DrawRect(ctx.RectProgram, rect.X+ctx.Zoom, top, rect.Width-2*ctx.Zoom, optionHeight, controlFocus)
+		}
+		if option.Disabled {
+			color = placeholderColor
+		}
+		TextLIB.DrawTextFace(ctx.Program, face, option.Label, rect.X+inset, top+(optionHeight-face.LineHeight(scale))/2+face.Ascent(scale), scale, color)
+	}
+}
+
+// popupRect is the layout rectangle of the open select's option list and
+// the height of one option.
+func (r *HTMLRenderer) popupRect(ctx *RenderContext) (Rect, float32) {
+	c := r.openSelect
+	d := c.box.Dimensions
+	style := c.box.Style
+	scale := style.FontScale * ctx.Zoom
+	inset := (controlPadding + 1) * ctx.Zoom
+	optionHeight := TextLIB.GetFace(style.Font).LineHeight(scale) + 2*controlPadding*ctx.Zoom
+
+	width := d.Width
+	for _, option := range c.Options {
+		width = max(width, r.measureText(style, option.Label, scale)+2*inset)
+	}
+
+	return Rect{X: d.X, Y: d.Y + d.Height, Width: width, Height: float32(len(c.Options)) * optionHeight}, optionHeight
+}
+
+func (r *HTMLRenderer) popupOptionAt(ctx *RenderContext, x, y float32) (int, bool) {
+	if r.openSelect == nil || r.openSelect.box == nil {
+		return 0, false
+	}
+
+	rect, optionHeight := r.popupRect(ctx)
+	y -= ctx.ScrollOffset
+	if !rect.contains(x, y) {
+		return 0, false
+	}
+
+	index := int((y - rect.Y) / optionHeight)
+	return index, index < len(r.openSelect.Options)
+}
+
+// clipText is the longest prefix of text that fits width.
+func (r *HTMLRenderer) clipText(style *BoxStyle, text string, scale, width float32) string {
+	if r.measureText(style, text, scale) <= width {
+		return text
+	}
+
+	end := 0
+	for i, ch := range text {
+		next := i + utf8.RuneLen(ch)
+		if r.measureText(style, text[:next], scale) > width {
+			break
+		}
+		end = next
+	}
+	return text[:end]
+}
+
+// fitText cuts text to width with an ellipsis.
+func (r *HTMLRenderer) fitText(style *BoxStyle, text string, scale, width float32) string {
+	if r.measureText(style, text, scale) <= width {
+		return text
+	}
+	return r.clipText(style, text, scale, width-r.measureText(style, "...", scale)) + "..."
+}
@@ -1,22 +1,43 @@
 package html
 
 import (
+	"context"
+	"log"
+	"net/url"
 	"strings"
-	"unicode"
 
+	h "github.com/RDLxxx/Himera/HDS/core/http"
+	"github.com/RDLxxx/Himera/HDS/core/web/css"
 	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
 	"golang.org/x/net/html"
 )
 
-func NewHTMLRenderer(htmlContent string) *HTMLRenderer {
+func NewHTMLRenderer(htmlContent string, pageURL string, ua string) *HTMLRenderer {
+	ctx, cancel := context.WithCancel(h.WithFirstParty(context.Background(), pageURL))
+
 	return &HTMLRenderer{
 		htmlContent: htmlContent,
-		textCache:   make(map[*html.Node]string),
+		pageURL:     pageURL,
+		baseURL:     pageURL,
+		userAgent:   ua,
 		layoutCache: make(map[*html.Node]*LayoutInfo),
-		HTMLstyle:   HTMLcfgStyle,
+		images:      newImageStore(),
+		controls:    make(map[*html.Node]*Control),
+		ctx:         ctx,
+		cancel:      cancel,
+		fetchCtx:    ctx,
 	}
 }
 
+// Parse parses the document and fetches its stylesheets with ctx, so it
+// can be done away from the render loop and given up on.
+func (r *HTMLRenderer) Parse(ctx context.Context) error {
+	r.fetchCtx = h.WithFirstParty(ctx, r.pageURL)
+	defer func() { r.fetchCtx = r.ctx }()
+
+	return r.ensureParsed()
+}
+
 func (r *HTMLRenderer) ensureParsed() error {
 	if r.parsed {
 		return nil
@@ -34,6 +55,8 @@ func (r *HTMLRenderer) ensureParsed() error {
 
 	r.cachedDoc = doc
 	r.bodyNode = findBodyNode(doc)
+	r.baseURL = documentBase(doc, r.pageURL)
+	r.stylesheets = r.loadStylesheets(doc)
 	r.parsed = true
 
 	return nil
@@ -46,499 +69,177 @@ func (r *HTMLRenderer) Render(ctx *RenderContext) error {
 		return err
 	}
 
-	if r.bodyNode != nil {
-		r.renderNode(ctx, r.bodyNode, ctx.X, ctx.Y)
-	} else if r.cachedDoc != nil {
-		r.renderNode(ctx, r.cachedDoc, ctx.X, ctx.Y)
+	if root := r.cachedLayout(ctx); root != nil {
+		r.paint(ctx, root)
 	}
 
 	return nil
 }
 
-func (r *HTMLRenderer) renderNode(ctx *RenderContext, node *html.Node, x, y float32) float32 {
-	currentY := y
-
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		switch child.Type {
-		case html.ElementNode:
-			if !shouldSkipElement(child.Data) {
-				currentY = r.renderElement(ctx, child, x, currentY)
-			}
-		case html.TextNode:
-			text := cleanText(child.Data)
-			if text != "" && child.Parent != nil && !shouldSkipElement(child.Parent.Data) {
-				currentY = r.renderText(ctx, text, x, currentY, r.HTMLstyle.BaseSize, r.HTMLstyle.TextColor)
-			}
-		}
+func (r *HTMLRenderer) CalculateContentHeight(ctx *RenderContext) float32 {
+	if err := r.ensureParsed(); err != nil {
+		return 100.0
 	}
 
-	return currentY
-}
-
-func (r *HTMLRenderer) renderElement(ctx *RenderContext, node *html.Node, x, y float32) float32 {
-	tag := strings.ToLower(node.Data)
-
-	content := r.getCachedText(node)
-
-	switch tag {
-	case "h1":
-		if content != "" {
-			y += r.HTMLstyle.H1MarginTop * ctx.Zoom
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.H1Size, r.HTMLstyle.HeadingColor)
-			y += r.HTMLstyle.H1MarginBottom * ctx.Zoom
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "h2":
-		if content != "" {
-			y += r.HTMLstyle.H2MarginTop * ctx.Zoom
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.H2Size, r.HTMLstyle.HeadingColor)
-			y += r.HTMLstyle.H2MarginBottom * ctx.Zoom
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "h3":
-		if content != "" {
-			y += r.HTMLstyle.H3MarginTop * ctx.Zoom
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.H3Size, r.HTMLstyle.HeadingColor)
-			y += r.HTMLstyle.H3MarginBottom * ctx.Zoom
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "h4":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.H4Size, r.HTMLstyle.HeadingColor)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "h5":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.H5Size, r.HTMLstyle.HeadingColor)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "h6":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.H6Size, r.HTMLstyle.HeadingColor)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "p":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.BaseSize, r.HTMLstyle.TextColor)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		}
-
-	case "div":
-		y = r.renderNode(ctx, node, x, y)
-		y += (r.HTMLstyle.ParagraphSpacing / 2) * ctx.Zoom
-
-	case "span":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.BaseSize, r.HTMLstyle.TextColor)
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "a":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.BaseSize, r.HTMLstyle.LinkColor)
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "strong", "b":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.BaseSize, r.HTMLstyle.TextColor)
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "em", "i":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.BaseSize, r.HTMLstyle.TextColor)
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "small":
-		if content != "" {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.SmallSize, r.HTMLstyle.TextColor)
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
-
-	case "br":
-		lineHeight := float32(TextLIB.FontMetrics.Height>>6) * ctx.Zoom * r.HTMLstyle.LineSpacing
-		y += lineHeight
-
-	case "hr":
-		y += 20 * ctx.Zoom
-
-	case "ul", "ol":
-		y = r.renderList(ctx, node, x, y, tag == "ol")
-
-	case "li":
-		y = r.renderListItem(ctx, node, x, y)
-
-	case "blockquote":
-		y = r.renderNode(ctx, node, x+r.HTMLstyle.IndentSize*ctx.Zoom, y)
-		y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-
-	default:
-		if content != "" && len(content) < 1000 {
-			y = r.renderText(ctx, content, x, y, r.HTMLstyle.BaseSize, r.HTMLstyle.TextColor)
-		} else {
-			y = r.renderNode(ctx, node, x, y)
-		}
+	root := r.cachedLayout(ctx)
+	if root == nil {
+		return 0
 	}
 
-	return y
+	return root.Dimensions.MarginBox().Height
 }
 
-func (r *HTMLRenderer) renderText(ctx *RenderContext, text string, x, y, scale float32, color [3]float32) float32 {
-	if text == "" {
-		return y
+// Title is the text of the document <title>, with whitespace collapsed.
+func (r *HTMLRenderer) Title() string {
+	if err := r.ensureParsed(); err != nil || r.cachedDoc == nil {
+		return ""
 	}
 
-	effectiveScale := scale * ctx.Zoom
-	lines := r.wrapText(text, ctx.Width-x, effectiveScale)
-	lineHeight := float32(TextLIB.FontMetrics.Height>>6) * effectiveScale * r.HTMLstyle.LineSpacing
-
-	currentY := y
-	for _, line := range lines {
-		if currentY+ctx.ScrollOffset > -lineHeight && currentY+ctx.ScrollOffset < ctx.Height+lineHeight {
-			TextLIB.DrawText(ctx.Program, line, x, currentY+ctx.ScrollOffset, effectiveScale, color)
+	var find func(*html.Node) *html.Node
+	find = func(node *html.Node) *html.Node {
+		if node.Type == html.ElementNode && strings.ToLower(node.Data) == "title" {
+			return node
 		}
-		currentY += lineHeight
-
-		if currentY+ctx.ScrollOffset > ctx.Height+lineHeight*10 {
-			break
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if found := find(child); found != nil {
+				return found
+			}
 		}
+		return nil
 	}
 
-	return currentY
+	title := find(r.cachedDoc)
+	if title == nil || title.FirstChild == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(title.FirstChild.Data), " ")
 }
 
-func (r *HTMLRenderer) renderList(ctx *RenderContext, node *html.Node, x, y float32, ordered bool) float32 {
-	currentY := y
-	itemNumber := 1
-
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		if child.Type == html.ElementNode && strings.ToLower(child.Data) == "li" {
-			var prefix string
-			if ordered {
-				prefix = strings.Repeat(" ", 2) + string(rune('0'+itemNumber)) + ". "
-				itemNumber++
-			} else {
-				prefix = "  • "
-			}
-
-			currentY = r.renderText(ctx, prefix, x, currentY, r.HTMLstyle.BaseSize, r.HTMLstyle.TextColor)
-
-			itemY := currentY - float32(TextLIB.FontMetrics.Height>>6)*ctx.Zoom*r.HTMLstyle.LineSpacing
-			currentY = r.renderNode(ctx, child, x+30*ctx.Zoom, itemY)
-			currentY += 5 * ctx.Zoom
-		}
-	}
+// URL is the address of the page, where its redirects ended up.
+func (r *HTMLRenderer) URL() string {
+	return r.pageURL
+}
 
-	return currentY + r.HTMLstyle.ParagraphSpacing*ctx.Zoom
+// Source is the HTML the renderer was created from.
+func (r *HTMLRenderer) Source() string {
+	return r.htmlContent
 }
 
-func (r *HTMLRenderer) renderListItem(ctx *RenderContext, node *html.Node, x, y float32) float32 {
-	return r.renderNode(ctx, node, x, y)
+// Invalidate drops the cached layout, the next Render or
+// CalculateContentHeight lays the document out again.
+func (r *HTMLRenderer) Invalidate() {
+	r.layoutRoot = nil
 }
 
-func (r *HTMLRenderer) wrapText(text string, maxWidth, scale float32) []string {
-	if maxWidth <= 0 {
-		return []string{text}
+func (r *HTMLRenderer) cachedLayout(ctx *RenderContext) *LayoutBox {
+	key := layoutKey{ctx.X, ctx.Y, ctx.Width, ctx.Height, ctx.Zoom, r.ResourceGeneration()}
+	if r.layoutRoot == nil || r.layoutKey != key {
+		r.layoutRoot = r.layout(ctx)
+		r.layoutKey = key
 	}
 
-	words := strings.Fields(text)
-	if len(words) == 0 {
-		return []string{}
-	}
+	return r.layoutRoot
+}
 
-	var lines []string
-	var currentLine strings.Builder
+func (r *HTMLRenderer) loadStylesheets(doc *html.Node) []*css.Stylesheet {
+	sheets := []*css.Stylesheet{userAgentStylesheet}
+
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch strings.ToLower(node.Data) {
+			case "style":
+				var text strings.Builder
+				for child := node.FirstChild; child != nil; child = child.NextSibling {
+					if child.Type == html.TextNode {
+						text.WriteString(child.Data)
+					}
+				}
+				sheets = append(sheets, r.resolveImports(css.Parse(text.String(), css.AuthorOrigin), r.baseURL, 0)...)
+				return
+
+			case "link":
+				rel := strings.Fields(strings.ToLower(getAttr(node, "rel")))
+				href := getAttr(node, "href")
+				if href != "" && containsString(rel, "stylesheet") && !containsString(rel, "alternate") {
+					sheets = append(sheets, r.fetchStylesheet(resolveURL(r.baseURL, href), 0)...)
+				}
+				return
 
-	for i, word := range words {
-		testLine := currentLine.String()
-		if testLine != "" {
-			testLine += " "
-		}
-		testLine += word
-
-		averageCharWidth := float32(TextLIB.FontMetrics.Height>>6) * 0.6
-		estimatedWidth := float32(len(testLine)) * averageCharWidth * scale
-
-		if estimatedWidth > maxWidth && currentLine.Len() > 0 {
-			lines = append(lines, currentLine.String())
-			currentLine.Reset()
-			currentLine.WriteString(word)
-		} else {
-			if i > 0 && currentLine.Len() > 0 {
-				currentLine.WriteString(" ")
+			case "noscript", "template":
+				return
 			}
-			currentLine.WriteString(word)
 		}
-	}
 
-	if currentLine.Len() > 0 {
-		lines = append(lines, currentLine.String())
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
 	}
+	walk(doc)
 
-	return lines
+	return sheets
 }
 
-func (r *HTMLRenderer) getCachedText(node *html.Node) string {
-	if cached, exists := r.textCache[node]; exists {
-		return cached
-	}
-
-	result := extractTextOptimized(node)
-
-	if len(r.textCache) > 1000 {
-		r.textCache = make(map[*html.Node]string)
+func (r *HTMLRenderer) fetchStylesheet(address string, depth int) []*css.Stylesheet {
+	if address == "" {
+		return nil
 	}
 
-	r.textCache[node] = result
-	return result
-}
-
-func (r *HTMLRenderer) CalculateContentHeight(ctx *RenderContext) float32 {
-	if err := r.ensureParsed(); err != nil {
-		return 100.0
+	resp, err := h.GETRequestContext(r.fetchCtx, address, r.userAgent)
+	if err != nil {
+		log.Printf("Stylesheet ? %s: %v", address, err)
+		return nil
 	}
-
-	tempCtx := *ctx
-	tempCtx.ScrollOffset = 0
-
-	var endY float32
-	if r.bodyNode != nil {
-		endY = r.calculateNodeHeight(&tempCtx, r.bodyNode, ctx.X, ctx.Y)
-	} else if r.cachedDoc != nil {
-		endY = r.calculateNodeHeight(&tempCtx, r.cachedDoc, ctx.X, ctx.Y)
+	if resp.StatusCode >= 400 {
+		log.Printf("Stylesheet ? %s: %s", address, resp.Status)
+		return nil
 	}
 
-	return endY - ctx.Y
+	return r.resolveImports(css.Parse(resp.Page, css.AuthorOrigin), resp.URL, depth)
 }
 
-func (r *HTMLRenderer) calculateNodeHeight(ctx *RenderContext, node *html.Node, x, y float32) float32 {
-	currentY := y
+func (r *HTMLRenderer) resolveImports(sheet *css.Stylesheet, base string, depth int) []*css.Stylesheet {
+	var sheets []*css.Stylesheet
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		switch child.Type {
-		case html.ElementNode:
-			if !shouldSkipElement(child.Data) {
-				currentY = r.calculateElementHeight(ctx, child, x, currentY)
-			}
-		case html.TextNode:
-			text := cleanText(child.Data)
-			if text != "" && child.Parent != nil && !shouldSkipElement(child.Parent.Data) {
-				parentTag := strings.ToLower(child.Parent.Data)
-				if !r.isTextHandledByElement(parentTag) {
-					currentY = r.calculateTextHeight(ctx, text, x, currentY, r.HTMLstyle.BaseSize)
-				}
-			}
+	if depth < 4 {
+		for _, href := range sheet.Imports {
+			sheets = append(sheets, r.fetchStylesheet(resolveURL(base, href), depth+1)...)
 		}
 	}
 
-	return currentY
+	return append(sheets, sheet)
 }
 
-func (r *HTMLRenderer) calculateElementHeight(ctx *RenderContext, node *html.Node, x, y float32) float32 {
-	tag := strings.ToLower(node.Data)
-	content := r.getCachedText(node)
-
-	switch tag {
-	case "h1":
-		if content != "" {
-			y += r.HTMLstyle.H1MarginTop * ctx.Zoom
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.H1Size)
-			y += r.HTMLstyle.H1MarginBottom * ctx.Zoom
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "h2":
-		if content != "" {
-			y += r.HTMLstyle.H2MarginTop * ctx.Zoom
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.H2Size)
-			y += r.HTMLstyle.H2MarginBottom * ctx.Zoom
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "h3":
-		if content != "" {
-			y += r.HTMLstyle.H3MarginTop * ctx.Zoom
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.H3Size)
-			y += r.HTMLstyle.H3MarginBottom * ctx.Zoom
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "h4":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.H4Size)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "h5":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.H5Size)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "h6":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.H6Size)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "p":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.BaseSize)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-			y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-		}
-
-	case "div":
-		y = r.calculateNodeHeight(ctx, node, x, y)
-		y += (r.HTMLstyle.ParagraphSpacing / 2) * ctx.Zoom
-
-	case "span":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.BaseSize)
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "a":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.BaseSize)
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "strong", "b":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.BaseSize)
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "em", "i":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.BaseSize)
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "small":
-		if content != "" {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.SmallSize)
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
-
-	case "br":
-		lineHeight := float32(TextLIB.FontMetrics.Height>>6) * ctx.Zoom * r.HTMLstyle.LineSpacing
-		y += lineHeight
-
-	case "hr":
-		y += 20 * ctx.Zoom
-
-	case "ul", "ol":
-		y = r.calculateListHeight(ctx, node, x, y, tag == "ol")
-
-	case "li":
-		y = r.calculateListItemHeight(ctx, node, x, y)
-
-	case "blockquote":
-		y = r.calculateNodeHeight(ctx, node, x+r.HTMLstyle.IndentSize*ctx.Zoom, y)
-		y += r.HTMLstyle.ParagraphSpacing * ctx.Zoom
-
-	default:
-		if content != "" && len(content) < 1000 {
-			y = r.calculateTextHeight(ctx, content, x, y, r.HTMLstyle.BaseSize)
-		} else {
-			y = r.calculateNodeHeight(ctx, node, x, y)
-		}
+func resolveURL(base, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
 	}
 
-	return y
-}
-
-func (r *HTMLRenderer) calculateTextHeight(ctx *RenderContext, text string, x, y, scale float32) float32 {
-	if text == "" {
-		return y
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref.String()
 	}
 
-	effectiveScale := scale * ctx.Zoom
-	lines := r.wrapText(text, ctx.Width-x, effectiveScale)
-	lineHeight := float32(TextLIB.FontMetrics.Height>>6) * effectiveScale * r.HTMLstyle.LineSpacing
-
-	return y + float32(len(lines))*lineHeight
+	return baseURL.ResolveReference(ref).String()
 }
 
-func (r *HTMLRenderer) calculateListHeight(ctx *RenderContext, node *html.Node, x, y float32, ordered bool) float32 {
-	currentY := y
-	itemNumber := 1
-
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		if child.Type == html.ElementNode && strings.ToLower(child.Data) == "li" {
-			var prefix string
-			if ordered {
-				prefix = strings.Repeat(" ", 2) + string(rune('0'+itemNumber)) + ". "
-				itemNumber++
-			} else {
-				prefix = "  • "
-			}
-
-			currentY = r.calculateTextHeight(ctx, prefix, x, currentY, r.HTMLstyle.BaseSize)
-
-			itemY := currentY - float32(TextLIB.FontMetrics.Height>>6)*ctx.Zoom*r.HTMLstyle.LineSpacing
-			currentY = r.calculateNodeHeight(ctx, child, x+30*ctx.Zoom, itemY)
-			currentY += 5 * ctx.Zoom
+func getAttr(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return attr.Val
 		}
 	}
-
-	return currentY + r.HTMLstyle.ParagraphSpacing*ctx.Zoom
+	return ""
 }
 
-func (r *HTMLRenderer) calculateListItemHeight(ctx *RenderContext, node *html.Node, x, y float32) float32 {
-	return r.calculateNodeHeight(ctx, node, x, y)
-}
-
-func (r *HTMLRenderer) isTextHandledByElement(tag string) bool {
-	switch tag {
-	case "h1", "h2", "h3", "h4", "h5", "h6", "p", "span", "a", "strong", "b", "em", "i", "small":
-		return true
-	default:
-		return false
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
 	}
+	return false
 }
 
 func findBodyNode(node *html.Node) *html.Node {
@@ -554,81 +255,3 @@ func findBodyNode(node *html.Node) *html.Node {
 
 	return nil
 }
-
-func shouldSkipElement(nodeName string) bool {
-	switch nodeName {
-	case "head", "title", "meta", "link", "script", "style", "noscript", "comment":
-		return true
-	default:
-		return false
-	}
-}
-
-func cleanText(text string) string {
-	if text == "" {
-		return ""
-	}
-
-	hasWhitespace := false
-	for _, r := range text {
-		if unicode.IsSpace(r) {
-			hasWhitespace = true
-			break
-		}
-	}
-
-	if !hasWhitespace {
-		return text
-	}
-
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return ""
-	}
-
-	result := make([]rune, 0, len(text))
-	prevSpace := false
-
-	for _, r := range text {
-		if unicode.IsSpace(r) {
-			if !prevSpace {
-				result = append(result, ' ')
-				prevSpace = true
-			}
-		} else {
-			result = append(result, r)
-			prevSpace = false
-		}
-	}
-
-	return strings.TrimSpace(string(result))
-}
-
-func extractTextOptimized(node *html.Node) string {
-	var parts []string
-
-	var walker func(*html.Node)
-	walker = func(n *html.Node) {
-		if n.Type == html.TextNode {
-			text := cleanText(n.Data)
-			if text != "" {
-				parts = append(parts, text)
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			walker(c)
-		}
-	}
-
-	walker(node)
-
-	if len(parts) == 0 {
-		return ""
-	}
-
-	if len(parts) == 1 {
-		return parts[0]
-	}
-
-	return strings.Join(parts, " ")
-}
@@ -0,0 +1,298 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	h "github.com/RDLxxx/Himera/HDS/core/http"
+	"github.com/RDLxxx/Himera/HDS/core/web/css"
+	drawer "github.com/RDLxxx/Himera/HGD/Draw/Drawer"
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+	"golang.org/x/net/html"
+)
+
+type imageState int
+
+const (
+	imageLoading imageState = iota
+	imageLoaded
+	imageFailed
+)
+
+type pageImage struct {
+	state         imageState
+	pixels        *image.NRGBA
+	width, height int
+	texture       uint32
+}
+
+// imageStore holds the images of a page. They are fetched and decoded in the
+// background, each finished image bumps generation so the layout is redone.
+type imageStore struct {
+	mu         sync.Mutex
+	images     map[string]*pageImage
+	generation atomic.Int64
+}
+
+func newImageStore() *imageStore {
+	return &imageStore{images: make(map[string]*pageImage)}
+}
+
+// ResourceGeneration changes every time an image of the page finished loading.
+func (r *HTMLRenderer) ResourceGeneration() int64 {
+	return r.images.generation.Load()
+}
+
+// Release stops loading the page images and frees their textures.
+func (r *HTMLRenderer) Release() {
+	r.cancel()
+
+	r.images.mu.Lock()
+	defer r.images.mu.Unlock()
+
+	for _, img := range r.images.images {
+		drawer.DeleteTexture(img.texture)
+		img.texture = 0
+	}
+}
+
+// imageInfo returns the state and intrinsic size of the image at address,
+// starting to load it the first time it is asked for.
+func (r *HTMLRenderer) imageInfo(address string) (imageState, int, int) {
+	s := r.images
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if img, ok := s.images[address]; ok {
+		return img.state, img.width, img.height
+	}
+
+	s.images[address] = &pageImage{state: imageLoading}
+	go r.loadImage(address)
+
+	return imageLoading, 0, 0
+}
+
+func (r *HTMLRenderer) loadImage(address string) {
+	pixels, err := fetchImage(r.ctx, address, r.userAgent)
+	if r.ctx.Err() != nil {
+		return
+	}
+
+	s := r.images
+	s.mu.Lock()
+	img := s.images[address]
+	if err != nil {
+		log.Printf("Image ? %s: %v", address, err)
+		img.state = imageFailed
+	} else {
+		img.state = imageLoaded
+		img.pixels = pixels
+		img.width, img.height = pixels.Rect.Dx(), pixels.Rect.Dy()
+	}
+	s.mu.Unlock()
+
+	s.generation.Add(1)
+	if r.ResourceLoaded != nil {
+		r.ResourceLoaded()
+	}
+}
+
+func fetchImage(ctx context.Context, address, ua string) (*image.NRGBA, error) {
+	var source io.Reader
+	if strings.HasPrefix(address, "data:") {
+		data, err := decodeDataURL(address)
+		if err != nil {
+			return nil, err
+		}
+		source = bytes.NewReader(data)
+	} else {
+		resp, err := h.NewRequest("GET", address).WithContext(ctx).WithUserAgent(ua).Do()
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return nil, errors.New(resp.Status)
+		}
+		source = resp.Body
+	}
+
+	decoded, _, err := image.Decode(source)
+	if err != nil {
+		return nil, err
+	}
+
+	pixels := image.NewNRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	draw.Draw(pixels, pixels.Rect, decoded, decoded.Bounds().Min, draw.Src)
+
+	return pixels, nil
+}
+
+func decodeDataURL(address string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(address, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("bad data url")
+	}
+
+	if strings.HasSuffix(header, ";base64") {
+		payload = strings.Join(strings.Fields(payload), "")
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	}
+
+	text, err := url.PathUnescape(payload)
+	return []byte(text), err
+}
+
+// texture returns the texture of a loaded image, uploading it on first use.
+func (r *HTMLRenderer) texture(address string) uint32 {
+	s := r.images
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	img := s.images[address]
+	if img == nil || img.state != imageLoaded {
+		return 0
+	}
+
+	if img.texture == 0 && img.pixels != nil {
+		img.texture = drawer.NewTexture(img.pixels)
+		img.pixels = nil
+	}
+
+	return img.texture
+}
+
+// buildImage sets up the box of an <img>. An image that failed to load is
+// replaced by its alt text unless the page gave it a size.
+func (r *HTMLRenderer) buildImage(box *LayoutBox) {
+	node := box.Node
+	box.Image = resolveURL(r.baseURL, getAttr(node, "src"))
+
+	state := imageFailed
+	if box.Image != "" {
+		state, _, _ = r.imageInfo(box.Image)
+	}
+
+	if state != imageFailed {
+		return
+	}
+
+	if _, _, sized := r.specifiedImageSize(box); sized && box.Type == InlineBox {
+		return
+	}
+
+	box.Image = ""
+	if alt := getAttr(node, "alt"); alt != "" {
+		text := &LayoutBox{
+			Type:  TextBox,
+			Node:  &html.Node{Type: html.TextNode, Data: alt},
+			Style: box.Style,
+			Text:  alt,
+		}
+		if box.Type == BlockBox {
+			text = &LayoutBox{Type: AnonymousBox, Node: node, Style: box.Style, Children: []*LayoutBox{text}}
+		}
+		box.Children = []*LayoutBox{text}
+	}
+}
+
+// specifiedLength reads a width or height the page gives a box through CSS
+// or the presentational attribute, in unzoomed pixels or as a percentage.
+func (r *HTMLRenderer) specifiedLength(box *LayoutBox, property string) (float32, bool, bool) {
+	if computed := r.styles[box.Node]; computed != nil {
+		value := computed.Get(property)
+		if length, ok := css.ParseLength(value); ok {
+			if length.Unit == "%" {
+				return length.Value, true, true
+			}
+			return r.toPx(value, box.Style.FontScale*TextLIB.FontSize), false, true
+		}
+	}
+
+	value := strings.TrimSpace(getAttr(box.Node, property))
+	pct := strings.HasSuffix(value, "%")
+	if n, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSuffix(value, "%"), "px"), 32); err == nil && n >= 0 {
+		return float32(n), pct, true
+	}
+
+	return 0, false, false
+}
+
+// specifiedImageSize is the size the page gives an image in unzoomed pixels,
+// -1 stands for auto.
+func (r *HTMLRenderer) specifiedImageSize(box *LayoutBox) (float32, float32, bool) {
+	read := func(property string) float32 {
+		if value, pct, ok := r.specifiedLength(box, property); ok && !pct {
+			return value
+		}
+		return -1
+	}
+
+	width, height := read("width"), read("height")
+	return width, height, width >= 0 || height >= 0
+}
+
+// atomicSize is the used size of an image or control box in zoomed pixels.
+func (r *HTMLRenderer) atomicSize(ctx *RenderContext, box *LayoutBox) (float32, float32) {
+	if box.Control != nil {
+		return r.controlSize(ctx, box)
+	}
+	return r.imageSize(ctx, box)
+}
+
+// imageSize is the used size of an image box in zoomed pixels.
+func (r *HTMLRenderer) imageSize(ctx *RenderContext, box *LayoutBox) (float32, float32) {
+	width, height, _ := r.specifiedImageSize(box)
+
+	state, iw, ih := imageLoading, 0, 0
+	if box.Image != "" {
+		state, iw, ih = r.imageInfo(box.Image)
+	}
+
+	if state == imageLoaded && iw > 0 && ih > 0 {
+		switch {
+		case width < 0 && height < 0:
+			width, height = float32(iw), float32(ih)
+		case width < 0:
+			width = height * float32(iw) / float32(ih)
+		case height < 0:
+			height = width * float32(ih) / float32(iw)
+		}
+	}
+
+	return max(width, 0) * ctx.Zoom, max(height, 0) * ctx.Zoom
+}
+
+func (r *HTMLRenderer) paintImage(ctx *RenderContext, box *LayoutBox) {
+	d := box.Dimensions
+	if texture := r.texture(box.Image); texture != 0 {
+		drawer.DrawImage(ctx.ImageProgram, texture, d.X, d.Y+ctx.ScrollOffset, d.Width, d.Height)
+		return
+	}
+
+	if state, _, _ := r.imageInfo(box.Image); state == imageFailed {
+		if alt := getAttr(box.Node, "alt"); alt != "" {
+			scale := box.Style.FontScale * ctx.Zoom
+			face := TextLIB.GetFace(box.Style.Font)
+			TextLIB.DrawTextFace(ctx.Program, face, alt, d.X, d.Y+face.Ascent(scale)+ctx.ScrollOffset, scale, box.Style.Color)
+		}
+	}
+}
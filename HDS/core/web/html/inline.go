@@ -0,0 +1,362 @@
+package html
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+)
+
+type inlineItem struct {
+	box    *LayoutBox
+	word   string
+	space  bool
+	br     bool
+	atomic bool
+
+	// noBreak forbids wrapping the line right before the item
+	noBreak bool
+}
+
+// cssWhiteSpace is the white space CSS collapses and breaks lines at, not
+// every Unicode space: U+00A0 must keep "10&nbsp;km" together.
+const cssWhiteSpace = " \t\n\r\f"
+
+// tabSize is the distance between tab stops in preserved text, in spaces.
+const tabSize = 8
+
+// inlineFlattener turns a tree of inline boxes into the items that are
+// placed on lines, applying the white-space property of each text box.
+type inlineFlattener struct {
+	items        []inlineItem
+	pendingSpace bool
+	column       int
+}
+
+type placedWord struct {
+	item   inlineItem
+	x      float32
+	width  float32
+	height float32
+}
+
+// layoutInlineContext lays out the inline children of an anonymous box as
+// line boxes. Words from different elements share a line and wrap together,
+// each keeping the style of the text box it came from.
+func (r *HTMLRenderer) layoutInlineContext(ctx *RenderContext, box *LayoutBox) {
+	d := &box.Dimensions
+
+	var f inlineFlattener
+	for _, child := range box.Children {
+		f.add(child)
+	}
+
+	var line []placedWord
+	x := float32(0)
+
+	for _, item := range f.items {
+		if item.br {
+			r.finishLine(ctx, box, line, item.box)
+			line, x = line[:0], 0
+			continue
+		}
+
+		scale := item.box.Style.FontScale * ctx.Zoom
+
+		var width, height float32
+		if item.atomic {
+			width, height = r.atomicSize(ctx, item.box)
+		} else {
+			width = r.measureText(item.box.Style, item.word, scale)
+		}
+
+		spaceWidth := float32(0)
+		if item.space && len(line) > 0 {
+			spaceWidth = r.measureText(item.box.Style, " ", scale)
+		}
+
+		if len(line) > 0 && !item.noBreak && x+spaceWidth+width > d.Width {
+			r.finishLine(ctx, box, line, nil)
+			line, x = line[:0], 0
+			spaceWidth = 0
+			item.space = false
+
+			// preserved spaces at a soft wrap do not indent the next line
+			if trimmed := strings.TrimLeft(item.word, " "); !item.atomic && trimmed != item.word {
+				item.word = trimmed
+				width = r.measureText(item.box.Style, item.word, scale)
+			}
+		}
+
+		line = append(line, placedWord{item: item, x: x + spaceWidth, width: width, height: height})
+		x += spaceWidth + width
+	}
+
+	if len(line) > 0 {
+		r.finishLine(ctx, box, line, nil)
+	}
+
+	for _, child := range box.Children {
+		r.inlineBounds(child)
+	}
+}
+
+func (f *inlineFlattener) add(box *LayoutBox) {
+	switch box.Type {
+	case TextBox:
+		box.Lines = box.Lines[:0]
+
+		switch ws := box.Style.WhiteSpace; ws {
+		case "pre", "pre-wrap":
+			f.addPreserved(box, ws == "pre-wrap")
+		default:
+			f.addCollapsed(box, ws == "pre-line", ws == "nowrap")
+		}
+
+	case InlineBox:
+		if strings.ToLower(box.Node.Data) == "br" {
+			f.items = append(f.items, inlineItem{box: box, br: true})
+			f.pendingSpace, f.column = false, 0
+			return
+		}
+		if box.atomic() {
+			f.items = append(f.items, inlineItem{box: box, atomic: true, space: f.pendingSpace, noBreak: !wraps(box.Style)})
+			f.pendingSpace = false
+			return
+		}
+
+		for _, child := range box.Children {
+			f.add(child)
+		}
+	}
+}
+
+func wraps(style *BoxStyle) bool {
+	return style.WhiteSpace != "nowrap" && style.WhiteSpace != "pre"
+}
+
+// addCollapsed splits text into words, runs of white space collapse into
+// one space. pre-line keeps the line breaks, nowrap never breaks.
+func (f *inlineFlattener) addCollapsed(box *LayoutBox, keepNewlines, nowrap bool) {
+	text := box.Text
+	for text != "" {
+		trimmed := strings.TrimLeft(text, cssWhiteSpace)
+		if gap := text[:len(text)-len(trimmed)]; gap != "" {
+			if breaks := strings.Count(gap, "\n"); keepNewlines && breaks > 0 {
+				for range breaks {
+					f.items = append(f.items, inlineItem{box: box, br: true})
+				}
+				f.pendingSpace = false
+			} else {
+				f.pendingSpace = true
+			}
+		}
+		if trimmed == "" {
+			break
+		}
+
+		end := strings.IndexAny(trimmed, cssWhiteSpace)
+		if end < 0 {
+			end = len(trimmed)
+		}
+
+		f.items = append(f.items, inlineItem{box: box, word: trimmed[:end], space: f.pendingSpace, noBreak: nowrap})
+		f.pendingSpace = false
+		text = trimmed[end:]
+	}
+}
+
+// addPreserved keeps spaces and line breaks and expands tabs. pre keeps
+// every line in one piece, pre-wrap may wrap after each run of spaces.
+func (f *inlineFlattener) addPreserved(box *LayoutBox, wrap bool) {
+	for i, line := range strings.Split(box.Text, "\n") {
+		if i > 0 {
+			f.items = append(f.items, inlineItem{box: box, br: true})
+			f.column = 0
+		}
+
+		line = f.expandTabs(strings.TrimSuffix(line, "\r"))
+		if line == "" {
+			continue
+		}
+
+		if !wrap {
+			f.items = append(f.items, inlineItem{box: box, word: line, noBreak: true})
+			continue
+		}
+
+		for line != "" {
+			start := len(line) - len(strings.TrimLeft(line, " "))
+			end := strings.IndexByte(line[start:], ' ')
+			if end < 0 {
+				end = len(line)
+			} else {
+				end += start
+			}
+			f.items = append(f.items, inlineItem{box: box, word: line[:end]})
+			line = line[end:]
+		}
+	}
+
+	f.pendingSpace = false
+}
+
+func (f *inlineFlattener) expandTabs(line string) string {
+	if !strings.ContainsRune(line, '\t') {
+		f.column += utf8.RuneCountInString(line)
+		return line
+	}
+
+	var out strings.Builder
+	for _, ch := range line {
+		if ch == '\t' {
+			spaces := tabSize - f.column%tabSize
+			out.WriteString(strings.Repeat(" ", spaces))
+			f.column += spaces
+			continue
+		}
+		out.WriteRune(ch)
+		f.column++
+	}
+
+	return out.String()
+}
+
+func (r *HTMLRenderer) finishLine(ctx *RenderContext, box *LayoutBox, line []placedWord, br *LayoutBox) {
+	d := &box.Dimensions
+	top := d.Y + d.Height
+
+	// Text is placed by its half-leading, atomic boxes sit on the baseline.
+	ascent, descent := float32(0), float32(0)
+	measure := func(style *BoxStyle) {
+		scale := style.FontScale * ctx.Zoom
+		lh := r.lineHeight(ctx, style)
+		face := TextLIB.GetFace(style.Font)
+		a := (lh-face.LineHeight(scale))/2 + face.Ascent(scale)
+		ascent = max(ascent, a)
+		descent = max(descent, lh-a)
+	}
+
+	hasText := false
+	for _, word := range line {
+		if word.item.atomic {
+			ascent = max(ascent, word.height)
+			continue
+		}
+		measure(word.item.box.Style)
+		hasText = true
+	}
+	if !hasText {
+		if br != nil {
+			measure(br.Style)
+		} else {
+			measure(box.Style)
+		}
+	}
+
+	lineHeight := ascent + descent
+	baseline := top + ascent
+
+	offset := float32(0)
+	if n := len(line); n > 0 {
+		free := d.Width - (line[n-1].x + line[n-1].width)
+		switch box.Style.TextAlign {
+		case "center":
+			offset = free / 2
+		case "right":
+			offset = free
+		}
+		if offset < 0 {
+			offset = 0
+		}
+	}
+
+	for i := 0; i < len(line); {
+		textBox := line[i].item.box
+		start := line[i]
+
+		if start.item.atomic {
+			textBox.Dimensions = LayoutInfo{
+				X:          d.X + offset + start.x,
+				Y:          baseline - start.height,
+				Width:      start.width,
+				Height:     start.height,
+				LineHeight: lineHeight,
+			}
+			i++
+			continue
+		}
+
+		var text strings.Builder
+		text.WriteString(start.item.word)
+		end := start.x + start.width
+
+		j := i + 1
+		for ; j < len(line) && line[j].item.box == textBox && !line[j].item.atomic; j++ {
+			if line[j].item.space {
+				text.WriteByte(' ')
+			}
+			text.WriteString(line[j].item.word)
+			end = line[j].x + line[j].width
+		}
+
+		textBox.Lines = append(textBox.Lines, TextLine{
+			Text:     text.String(),
+			X:        d.X + offset + start.x,
+			Y:        top,
+			Width:    end - start.x,
+			Height:   lineHeight,
+			Baseline: baseline,
+		})
+		i = j
+	}
+
+	if br != nil {
+		br.Dimensions = LayoutInfo{X: d.X, Y: top, Height: lineHeight, LineHeight: lineHeight}
+	}
+
+	d.Height += lineHeight
+}
+
+func (r *HTMLRenderer) inlineBounds(box *LayoutBox) (Rect, bool) {
+	var bounds Rect
+	found := false
+
+	extend := func(rect Rect) {
+		if !found {
+			bounds, found = rect, true
+			return
+		}
+		right := max(bounds.X+bounds.Width, rect.X+rect.Width)
+		bottom := max(bounds.Y+bounds.Height, rect.Y+rect.Height)
+		bounds.X = min(bounds.X, rect.X)
+		bounds.Y = min(bounds.Y, rect.Y)
+		bounds.Width = right - bounds.X
+		bounds.Height = bottom - bounds.Y
+	}
+
+	for _, line := range box.Lines {
+		extend(Rect{X: line.X, Y: line.Y, Width: line.Width, Height: line.Height})
+	}
+	for _, child := range box.Children {
+		if rect, ok := r.inlineBounds(child); ok {
+			extend(rect)
+		}
+	}
+
+	if box.Type == InlineBox && (box.atomic() || strings.ToLower(box.Node.Data) == "br") {
+		return box.Dimensions.ContentBox(), true
+	}
+
+	box.Dimensions.X, box.Dimensions.Y = bounds.X, bounds.Y
+	box.Dimensions.Width, box.Dimensions.Height = bounds.Width, bounds.Height
+	if box.Type == InlineBox && found {
+		r.layoutCache[box.Node] = &box.Dimensions
+	}
+
+	return bounds, found
+}
+
+func (r *HTMLRenderer) measureText(style *BoxStyle, text string, scale float32) float32 {
+	return TextLIB.MeasureTextFace(TextLIB.GetFace(style.Font), text, scale)
+}
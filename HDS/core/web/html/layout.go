@@ -0,0 +1,241 @@
+package html
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+	"golang.org/x/net/html"
+)
+
+func (r *HTMLRenderer) buildLayoutTree(node *html.Node, parent *BoxStyle) *LayoutBox {
+	switch node.Type {
+	case html.TextNode:
+		if node.Data == "" {
+			return nil
+		}
+		return &LayoutBox{Type: TextBox, Node: node, Style: parent, Text: node.Data}
+
+	case html.DocumentNode:
+		box := &LayoutBox{Type: BlockBox, Node: node, Style: parent}
+		r.buildChildren(box)
+		return box
+
+	case html.ElementNode:
+		style := r.styleFor(node, parent)
+		box := &LayoutBox{Node: node, Style: style}
+
+		switch style.Display {
+		case "none", "table-column", "table-column-group":
+			return nil
+		case "block", "list-item", "table-caption", "table-row-group", "table-header-group", "table-footer-group":
+			box.Type = BlockBox
+		case "table":
+			box.Type = TableBox
+		case "table-row":
+			box.Type = TableRowBox
+		case "table-cell":
+			box.Type = TableCellBox
+		default:
+			box.Type = InlineBox
+		}
+
+		if style.Display == "list-item" {
+			box.Marker = listMarker(node)
+		}
+
+		if strings.ToLower(node.Data) == "img" {
+			r.buildImage(box)
+			return box
+		}
+		if isControlElement(node) {
+			if !r.buildControl(box) {
+				return nil
+			}
+			return box
+		}
+
+		r.buildChildren(box)
+		return box
+	}
+
+	return nil
+}
+
+func (r *HTMLRenderer) buildChildren(box *LayoutBox) {
+	var children []*LayoutBox
+	hasBlock := false
+
+	for child := box.Node.FirstChild; child != nil; child = child.NextSibling {
+		if childBox := r.buildLayoutTree(child, box.Style); childBox != nil {
+			hasBlock = hasBlock || isBlockLevel(childBox)
+			children = append(children, childBox)
+		}
+	}
+
+	switch {
+	case box.Type == TableBox:
+		buildTable(box, children)
+		return
+	case box.Type == TableRowBox:
+		buildRow(box, children)
+		return
+	case isRowGroup(box.Style.Display):
+		box.Children = children
+		return
+	}
+
+	// rows and cells only mean something inside a table
+	for _, child := range children {
+		if child.Type == TableRowBox || child.Type == TableCellBox {
+			child.Type = BlockBox
+			hasBlock = true
+		}
+	}
+
+	// An inline element wrapping blocks (<a><div>..</div></a>) is laid out
+	// as a block, its inline styling is still inherited by the children.
+	if box.Type == InlineBox && hasBlock {
+		box.Type = BlockBox
+	}
+
+	if box.Type != BlockBox && box.Type != TableCellBox {
+		box.Children = children
+		return
+	}
+
+	for _, child := range children {
+		if isBlockLevel(child) {
+			box.Children = append(box.Children, child)
+			continue
+		}
+
+		last := len(box.Children) - 1
+		if last < 0 || box.Children[last].Type != AnonymousBox {
+			box.Children = append(box.Children, &LayoutBox{Type: AnonymousBox, Node: box.Node, Style: box.Style})
+			last++
+		}
+		box.Children[last].Children = append(box.Children[last].Children, child)
+	}
+
+	kept := box.Children[:0]
+	for _, child := range box.Children {
+		if child.Type != AnonymousBox || !isCollapsibleWhitespace(child.Children) {
+			kept = append(kept, child)
+		}
+	}
+	box.Children = kept
+}
+
+func isBlockLevel(box *LayoutBox) bool {
+	return box.Type == BlockBox || box.Type == TableBox
+}
+
+func isCollapsibleWhitespace(boxes []*LayoutBox) bool {
+	for _, box := range boxes {
+		if box.Type != TextBox || strings.Trim(box.Text, cssWhiteSpace) != "" ||
+			box.Style.WhiteSpace == "pre" || box.Style.WhiteSpace == "pre-wrap" {
+			return false
+		}
+	}
+	return true
+}
+
+func listMarker(node *html.Node) string {
+	if node.Parent == nil || strings.ToLower(node.Parent.Data) != "ol" {
+		return "•"
+	}
+
+	index := 1
+	for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == html.ElementNode && strings.ToLower(sibling.Data) == "li" {
+			index++
+		}
+	}
+
+	return strconv.Itoa(index) + "."
+}
+
+func (r *HTMLRenderer) layout(ctx *RenderContext) *LayoutBox {
+	r.computeStyles(ctx)
+	r.controlOrder = r.controlOrder[:0]
+
+	var root *LayoutBox
+	if r.bodyNode != nil {
+		root = r.buildLayoutTree(r.bodyNode, r.rootStyle())
+	} else if r.cachedDoc != nil {
+		root = r.buildLayoutTree(r.cachedDoc, r.rootStyle())
+	}
+
+	if root == nil {
+		return nil
+	}
+
+	clear(r.layoutCache)
+
+	viewport := &LayoutInfo{X: ctx.X, Y: ctx.Y, Width: ctx.Width}
+	r.layoutBox(ctx, root, viewport)
+
+	return root
+}
+
+func (r *HTMLRenderer) layoutBox(ctx *RenderContext, box *LayoutBox, containing *LayoutInfo) {
+	switch box.Type {
+	case BlockBox, TableRowBox, TableCellBox:
+		r.layoutBlock(ctx, box, containing)
+	case TableBox:
+		r.layoutTable(ctx, box, containing)
+	case AnonymousBox:
+		r.layoutAnonymous(ctx, box, containing)
+	}
+}
+
+func (r *HTMLRenderer) layoutBlock(ctx *RenderContext, box *LayoutBox, containing *LayoutInfo) {
+	d := &box.Dimensions
+	d.Margin = resolveEdges(box.Style.Margin, box.Style.MarginPct, ctx.Zoom, containing.Width)
+	d.Border = box.Style.Border.Scale(ctx.Zoom)
+	d.Padding = resolveEdges(box.Style.Padding, box.Style.PaddingPct, ctx.Zoom, containing.Width)
+
+	d.Width = containing.Width -
+		d.Margin.Left - d.Margin.Right -
+		d.Border.Left - d.Border.Right -
+		d.Padding.Left - d.Padding.Right
+	if d.Width < 0 {
+		d.Width = 0
+	}
+
+	d.X = containing.X + d.Margin.Left + d.Border.Left + d.Padding.Left
+	d.Y = containing.Y + containing.Height + d.Margin.Top + d.Border.Top + d.Padding.Top
+	d.Height = 0
+	d.LineHeight = r.lineHeight(ctx, box.Style)
+
+	if box.atomic() {
+		d.Width, d.Height = r.atomicSize(ctx, box)
+		r.layoutCache[box.Node] = d
+		return
+	}
+
+	for _, child := range box.Children {
+		r.layoutBox(ctx, child, d)
+		d.Height += child.Dimensions.MarginBox().Height
+	}
+
+	if box.Node != nil && box.Node.Type == html.ElementNode {
+		r.layoutCache[box.Node] = d
+	}
+}
+
+func (r *HTMLRenderer) layoutAnonymous(ctx *RenderContext, box *LayoutBox, containing *LayoutInfo) {
+	d := &box.Dimensions
+	d.X = containing.X
+	d.Y = containing.Y + containing.Height
+	d.Width = containing.Width
+	d.Height = 0
+	d.LineHeight = r.lineHeight(ctx, box.Style)
+
+	r.layoutInlineContext(ctx, box)
+}
+
+func (r *HTMLRenderer) lineHeight(ctx *RenderContext, style *BoxStyle) float32 {
+	return TextLIB.GetFace(style.Font).LineHeight(style.FontScale*ctx.Zoom) * style.LineSpacing
+}
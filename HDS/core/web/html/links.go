@@ -0,0 +1,178 @@
+package html
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LinkAt returns the resolved target of the link under the window point
+// x, y, or "" when there is none.
+func (r *HTMLRenderer) LinkAt(ctx *RenderContext, x, y float32) string {
+	if err := r.ensureParsed(); err != nil {
+		return ""
+	}
+
+	root := r.cachedLayout(ctx)
+	if root == nil {
+		return ""
+	}
+
+	box := hitTest(root, x, y-ctx.ScrollOffset)
+	if box == nil {
+		return ""
+	}
+
+	for node := box.Node; node != nil; node = node.Parent {
+		if node.Type != html.ElementNode || strings.ToLower(node.Data) != "a" {
+			continue
+		}
+
+		href := strings.TrimSpace(getAttr(node, "href"))
+		if href == "" && !hasAttr(node, "href") {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return ""
+		}
+		return resolveURL(r.baseURL, href)
+	}
+
+	return ""
+}
+
+// hitTest returns the deepest visible box under the point, later siblings
+// are painted over earlier ones so they are tried first.
+func hitTest(box *LayoutBox, x, y float32) *LayoutBox {
+	for i := len(box.Children) - 1; i >= 0; i-- {
+		if hit := hitTest(box.Children[i], x, y); hit != nil {
+			return hit
+		}
+	}
+
+	if !box.Style.Visible {
+		return nil
+	}
+
+	for _, line := range box.Lines {
+		if (Rect{X: line.X, Y: line.Y, Width: line.Width, Height: line.Height}).contains(x, y) {
+			return box
+		}
+	}
+
+	switch box.Type {
+	case BlockBox, TableBox, TableRowBox, TableCellBox:
+		if box.Node != nil && box.Node.Type == html.ElementNode && box.Dimensions.BorderBox().contains(x, y) {
+			return box
+		}
+	case InlineBox:
+		if box.atomic() && box.Dimensions.ContentBox().contains(x, y) {
+			return box
+		}
+	}
+
+	return nil
+}
+
+// AnchorOffset returns the document y of the element a fragment points at,
+// relative to the top of the page.
+func (r *HTMLRenderer) AnchorOffset(ctx *RenderContext, fragment string) (float32, bool) {
+	if fragment == "" || r.ensureParsed() != nil || r.cachedDoc == nil {
+		return 0, false
+	}
+
+	target := findAnchor(r.cachedDoc, fragment)
+	if target == nil {
+		return 0, false
+	}
+
+	root := r.cachedLayout(ctx)
+	if root == nil {
+		return 0, false
+	}
+
+	var find func(box *LayoutBox) (float32, bool)
+	find = func(box *LayoutBox) (float32, bool) {
+		if box.Node == target && box.Type != AnonymousBox {
+			return box.Dimensions.BorderBox().Y, true
+		}
+		for _, child := range box.Children {
+			if y, ok := find(child); ok {
+				return y, true
+			}
+		}
+		return 0, false
+	}
+
+	y, ok := find(root)
+	return y - ctx.Y, ok
+}
+
+func findAnchor(node *html.Node, fragment string) *html.Node {
+	if node.Type == html.ElementNode {
+		if getAttr(node, "id") == fragment ||
+			(strings.ToLower(node.Data) == "a" && getAttr(node, "name") == fragment) {
+			return node
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findAnchor(child, fragment); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// documentBase is the page URL, overridden by the first <base href>.
+func documentBase(doc *html.Node, pageURL string) string {
+	var base string
+
+	var walk func(*html.Node) bool
+	walk = func(node *html.Node) bool {
+		if node.Type == html.ElementNode && strings.ToLower(node.Data) == "base" && hasAttr(node, "href") {
+			base = resolveURL(pageURL, getAttr(node, "href"))
+			return true
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	walk(doc)
+
+	if base == "" {
+		return pageURL
+	}
+	return base
+}
+
+// SplitFragment splits an address into the document part and its fragment.
+func SplitFragment(address string) (string, string) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return address, ""
+	}
+
+	fragment := u.Fragment
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), fragment
+}
+
+func hasAttr(node *html.Node, name string) bool {
+	for _, attr := range node.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rect Rect) contains(x, y float32) bool {
+	return x >= rect.X && x < rect.X+rect.Width && y >= rect.Y && y < rect.Y+rect.Height
+}
@@ -0,0 +1,128 @@
+package html
+
+import (
+	drawer "github.com/RDLxxx/Himera/HGD/Draw/Drawer"
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+)
+
+func (r *HTMLRenderer) paint(ctx *RenderContext, root *LayoutBox) {
+	canvas := root.Style
+	if !canvas.HasBackground {
+		canvas = r.rootStyle()
+	}
+	if canvas.HasBackground {
+		drawer.DrawRect(ctx.RectProgram, 0, 0, ctx.X*2+ctx.Width, ctx.Y*2+ctx.Height, canvas.Background)
+	}
+
+	for _, child := range root.Children {
+		r.paintBox(ctx, child)
+	}
+
+	r.paintSelectPopup(ctx)
+}
+
+func (r *HTMLRenderer) paintBox(ctx *RenderContext, box *LayoutBox) {
+	border := box.Dimensions.BorderBox()
+	if border.Y+ctx.ScrollOffset > ctx.Y+ctx.Height+box.Dimensions.LineHeight ||
+		border.Y+border.Height+ctx.ScrollOffset < 0 {
+		return
+	}
+
+	visible := box.Style.Visible
+
+	if visible && box.Style.HasBackground {
+		switch box.Type {
+		case BlockBox, TableBox, TableRowBox, TableCellBox:
+			drawer.DrawRect(ctx.RectProgram, border.X, border.Y+ctx.ScrollOffset, border.Width, border.Height, box.Style.Background)
+		case InlineBox:
+			r.paintInlineBackground(ctx, box, box.Style.Background)
+		}
+	}
+
+	if visible && box.Type != AnonymousBox && box.Type != TextBox && box.Type != InlineBox {
+		paintBorders(ctx, box, border)
+	}
+
+	if visible && box.Image != "" {
+		r.paintImage(ctx, box)
+	}
+
+	if visible && box.Control != nil {
+		r.paintControl(ctx, box)
+	}
+
+	if visible && box.Marker != "" {
+		r.paintMarker(ctx, box)
+	}
+
+	if visible && box.Type == TextBox {
+		scale := box.Style.FontScale * ctx.Zoom
+		face := TextLIB.GetFace(box.Style.Font)
+		for _, line := range box.Lines {
+			if line.Y+line.Height+ctx.ScrollOffset < 0 || line.Y+ctx.ScrollOffset > ctx.Y+ctx.Height+line.Height {
+				continue
+			}
+			TextLIB.DrawTextFace(ctx.Program, face, line.Text, line.X, line.Baseline+ctx.ScrollOffset, scale, box.Style.Color)
+		}
+	}
+
+	for _, child := range box.Children {
+		r.paintBox(ctx, child)
+	}
+}
+
+func paintBorders(ctx *RenderContext, box *LayoutBox, border Rect) {
+	b := box.Dimensions.Border
+	colors := box.Style.BorderColor
+	y := border.Y + ctx.ScrollOffset
+
+	if b.Top > 0 {
+		drawer.DrawRect(ctx.RectProgram, border.X, y, border.Width, b.Top, colors[0])
+	}
+	if b.Right > 0 {
+		drawer.DrawRect(ctx.RectProgram, border.X+border.Width-b.Right, y, b.Right, border.Height, colors[1])
+	}
+	if b.Bottom > 0 {
+		drawer.DrawRect(ctx.RectProgram, border.X, y+border.Height-b.Bottom, border.Width, b.Bottom, colors[2])
+	}
+	if b.Left > 0 {
+		drawer.DrawRect(ctx.RectProgram, border.X, y, b.Left, border.Height, colors[3])
+	}
+}
+
+func (r *HTMLRenderer) paintInlineBackground(ctx *RenderContext, box *LayoutBox, color [3]float32) {
+	for _, line := range box.Lines {
+		drawer.DrawRect(ctx.RectProgram, line.X, line.Y+ctx.ScrollOffset, line.Width, line.Height, color)
+	}
+
+	for _, child := range box.Children {
+		r.paintInlineBackground(ctx, child, color)
+	}
+}
+
+func (r *HTMLRenderer) paintMarker(ctx *RenderContext, box *LayoutBox) {
+	baseline, ok := firstBaseline(box)
+	if !ok {
+		baseline = box.Dimensions.Y + TextLIB.GetFontAscent(box.Style.FontScale*ctx.Zoom)
+	}
+
+	scale := box.Style.FontScale * ctx.Zoom
+	width, _ := TextLIB.GetTextDimensions(box.Marker, scale)
+	x := box.Dimensions.X - width - 8*ctx.Zoom
+
+	TextLIB.DrawText(ctx.Program, box.Marker, x, baseline+ctx.ScrollOffset, scale, box.Style.Color)
+}
+
+func firstBaseline(box *LayoutBox) (float32, bool) {
+	if len(box.Lines) > 0 {
+		return box.Lines[0].Baseline, true
+	}
+
+	for _, child := range box.Children {
+		if baseline, ok := firstBaseline(child); ok {
+			return baseline, true
+		}
+	}
+
+	return 0, false
+}
@@ -1,28 +1,356 @@
 package html
 
 import (
-	"github.com/RDLxxx/Himera/HGD/utils"
+	"strconv"
+	"strings"
+
+	"github.com/RDLxxx/Himera/HDS/core/web/css"
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+	"golang.org/x/net/html"
 )
 
-var HTMLcfgStyle = &HTMLConfig{
-	TextColor:    utils.RGBToFloat32(240, 240, 240),
-	LinkColor:    utils.RGBToFloat32(100, 149, 237),
-	HeadingColor: utils.RGBToFloat32(255, 255, 255),
-
-	H1Size:    2.0,
-	H2Size:    1.5,
-	H3Size:    1.17,
-	BaseSize:  1.0,
-	SmallSize: 0.8,
-
-	ParagraphSpacing: 16.0,
-	LineSpacing:      1.4,
-	IndentSize:       20.0,
-
-	H1MarginTop:    24.0,
-	H1MarginBottom: 16.0,
-	H2MarginTop:    20.0,
-	H2MarginBottom: 12.0,
-	H3MarginTop:    16.0,
-	H3MarginBottom: 8.0,
+const defaultStylesheet = `
+html, body, address, article, aside, center, details, dialog, dir, div, dl, dt,
+fieldset, figcaption, figure, footer, form, header, hgroup, main, menu, nav,
+section, summary, pre, h1, h2, h3, h4, h5, h6, p, dd, blockquote,
+hr, ul, ol, legend, optgroup {
+	display: block;
+}
+
+table { display: table; border-spacing: 2px; margin-bottom: 16px }
+caption { display: table-caption; text-align: center }
+thead { display: table-header-group; vertical-align: middle }
+tbody { display: table-row-group; vertical-align: middle }
+tfoot { display: table-footer-group; vertical-align: middle }
+tr { display: table-row; vertical-align: inherit }
+td, th { display: table-cell; padding: 4px 8px; vertical-align: inherit }
+th { font-weight: bold; text-align: center; color: #ffffff }
+col { display: table-column }
+colgroup { display: table-column-group }
+table[border] { border: 1px outset rgb(128, 128, 128) }
+table[border] > * > tr > td, table[border] > * > tr > th { border: 1px inset rgb(128, 128, 128) }
+
+head, title, meta, link, script, style, noscript, template, base, datalist,
+area, map, param, [hidden] {
+	display: none;
+}
+
+li { display: list-item; margin-bottom: 5px }
+
+html { color: rgb(240, 240, 240); line-height: 1.4 }
+
+h1, h2, h3, h4, h5, h6 { color: #ffffff; font-weight: bold }
+h1 { font-size: 2em; margin-top: 24px; margin-bottom: 16px }
+h2 { font-size: 1.5em; margin-top: 20px; margin-bottom: 12px }
+h3 { font-size: 1.17em; margin-top: 16px; margin-bottom: 8px }
+h4 { font-size: 1em; margin-bottom: 16px }
+h5 { font-size: 0.83em; margin-bottom: 16px }
+h6 { font-size: 0.67em; margin-bottom: 16px }
+
+div { margin-bottom: 8px }
+p { margin-bottom: 16px }
+dd { margin-left: 40px }
+blockquote { margin-left: 20px; margin-bottom: 16px }
+hr { margin-top: 8px; margin-bottom: 8px }
+ul, ol { padding-left: 30px; margin-bottom: 16px }
+li ul, li ol { margin-bottom: 0 }
+
+a { color: rgb(100, 149, 237) }
+small { font-size: 0.8em }
+b, strong, th, dt { font-weight: bold }
+i, em, cite, var, dfn, address { font-style: italic }
+pre, code, kbd, samp, tt, listing, xmp, plaintext { font-family: monospace }
+pre, listing, xmp, plaintext {
+	white-space: pre; margin-bottom: 16px; padding: 8px;
+	background-color: rgb(40, 40, 40)
+}
+code, kbd, samp { background-color: rgb(48, 48, 48) }
+pre code, pre kbd, pre samp { background-color: transparent }
+nobr { white-space: nowrap }
+`
+
+var userAgentStylesheet = css.Parse(defaultStylesheet, css.UserAgentOrigin)
+
+func (r *HTMLRenderer) computeStyles(ctx *RenderContext) {
+	media := css.Media{
+		Width:    ctx.Width / ctx.Zoom,
+		Height:   ctx.Height / ctx.Zoom,
+		FontSize: TextLIB.FontSize,
+	}
+	if r.styles != nil && r.styleMedia == media {
+		return
+	}
+
+	r.styles = css.Compute(r.cachedDoc, r.stylesheets, media)
+	r.styleMedia = media
+	r.rootFontSize = media.FontSize
+
+	if r.bodyNode != nil && r.bodyNode.Parent != nil {
+		if size, ok := css.ParseLength(r.styles[r.bodyNode.Parent].Get("font-size")); ok {
+			r.rootFontSize = size.Value
+		}
+	}
+}
+
+func (r *HTMLRenderer) styleFor(node *html.Node, parent *BoxStyle) *BoxStyle {
+	computed := r.styles[node]
+	style := &BoxStyle{
+		Display:     "inline",
+		Visible:     parent.Visible,
+		Color:       parent.Color,
+		FontScale:   parent.FontScale,
+		LineSpacing: parent.LineSpacing,
+		TextAlign:   parent.TextAlign,
+		WhiteSpace:  parent.WhiteSpace,
+	}
+
+	switch display := computed.Get("display"); display {
+	case "", "inline", "inline-block", "inline-flex", "inline-grid", "contents":
+		style.Display = "inline"
+	case "none", "list-item", "table", "table-row", "table-cell", "table-caption",
+		"table-row-group", "table-header-group", "table-footer-group", "table-column", "table-column-group":
+		style.Display = display
+	case "inline-table":
+		style.Display = "table"
+	default:
+		style.Display = "block"
+	}
+
+	switch computed.Get("visibility") {
+	case "hidden", "collapse":
+		style.Visible = false
+	case "visible":
+		style.Visible = true
+	}
+
+	if color, ok := css.ParseColor(computed.Get("color")); ok {
+		style.Color = color
+	}
+
+	if value := computed.Get("background-color"); !css.IsTransparent(value) {
+		style.Background, style.HasBackground = css.ParseColor(value)
+	}
+
+	switch whiteSpace := computed.Get("white-space"); whiteSpace {
+	case "normal", "pre", "nowrap", "pre-wrap", "pre-line":
+		style.WhiteSpace = whiteSpace
+	case "break-spaces":
+		style.WhiteSpace = "pre-wrap"
+	}
+
+	switch align := computed.Get("text-align"); align {
+	case "left", "right", "center", "justify":
+		style.TextAlign = align
+	case "start":
+		style.TextAlign = "left"
+	case "end":
+		style.TextAlign = "right"
+	}
+
+	fontSize := parent.FontScale * TextLIB.FontSize
+	if length, ok := css.ParseLength(computed.Get("font-size")); ok && length.Unit == "px" {
+		fontSize = length.Value
+		style.FontScale = fontSize / TextLIB.FontSize
+	}
+
+	style.Font = TextLIB.FaceKey{
+		Family: fontFamily(computed.Get("font-family")),
+		Bold:   isBold(computed.Get("font-weight"), parent.Font.Bold),
+		Italic: parent.Font.Italic,
+	}
+	switch computed.Get("font-style") {
+	case "italic", "oblique":
+		style.Font.Italic = true
+	case "normal":
+		style.Font.Italic = false
+	}
+
+	if value, ok := computed.Specified("line-height"); ok {
+		style.LineSpacing = r.lineSpacing(value, fontSize, parent.LineSpacing)
+	}
+
+	edges := func(format string) (EdgeSizes, EdgeSizes) {
+		var px, pct EdgeSizes
+		for _, side := range []struct {
+			name    string
+			px, pct *float32
+		}{
+			{"top", &px.Top, &pct.Top},
+			{"right", &px.Right, &pct.Right},
+			{"bottom", &px.Bottom, &pct.Bottom},
+			{"left", &px.Left, &pct.Left},
+		} {
+			value := computed.Get(strings.Replace(format, "%s", side.name, 1))
+			if length, ok := css.ParseLength(value); ok && length.Unit == "%" {
+				*side.pct = length.Value / 100
+				continue
+			}
+			*side.px = r.toPx(value, fontSize)
+		}
+		return px, pct
+	}
+
+	style.Margin, style.MarginPct = edges("margin-%s")
+	style.Padding, style.PaddingPct = edges("padding-%s")
+	style.Border, _ = edges("border-%s-width")
+
+	for i, side := range []struct {
+		name  string
+		width *float32
+	}{
+		{"top", &style.Border.Top},
+		{"right", &style.Border.Right},
+		{"bottom", &style.Border.Bottom},
+		{"left", &style.Border.Left},
+	} {
+		switch computed.Get("border-" + side.name + "-style") {
+		case "", "none", "hidden":
+			*side.width = 0
+		}
+
+		style.BorderColor[i] = style.Color
+		if color, ok := css.ParseColor(computed.Get("border-" + side.name + "-color")); ok {
+			style.BorderColor[i] = color
+		}
+	}
+
+	style.VerticalAlign = computed.Get("vertical-align")
+	if style.Display == "table" {
+		if spacing := css.SplitValues(computed.Get("border-spacing")); len(spacing) > 0 && computed.Get("border-collapse") != "collapse" {
+			style.BorderSpacing = r.toPx(spacing[0], fontSize)
+		}
+		if spacing, err := strconv.ParseFloat(getAttr(node, "cellspacing"), 32); err == nil {
+			style.BorderSpacing = float32(spacing)
+		}
+	}
+
+	return style
+}
+
+// fontFamily picks the first family of the list we can tell apart, only
+// monospace gets its own face.
+func fontFamily(value string) string {
+	for _, family := range strings.Split(value, ",") {
+		family = strings.ToLower(strings.Trim(strings.TrimSpace(family), `"'`))
+		switch {
+		case family == "monospace" || strings.Contains(family, "mono") || strings.Contains(family, "courier") ||
+			family == "consolas" || family == "menlo" || family == "monaco":
+			return TextLIB.FamilyMonospace
+		case family == "sans-serif" || family == "serif" || family == "system-ui":
+			return TextLIB.FamilyDefault
+		}
+	}
+	return TextLIB.FamilyDefault
+}
+
+func isBold(weight string, inherited bool) bool {
+	switch weight {
+	case "bold", "bolder":
+		return true
+	case "normal", "lighter":
+		return false
+	}
+	if n, err := strconv.Atoi(weight); err == nil {
+		return n >= 600
+	}
+	return inherited
+}
+
+func (r *HTMLRenderer) rootStyle() *BoxStyle {
+	style := &BoxStyle{
+		Display:     "block",
+		Visible:     true,
+		Color:       [3]float32{1, 1, 1},
+		FontScale:   1.0,
+		LineSpacing: 1.2,
+		TextAlign:   "left",
+		WhiteSpace:  "normal",
+	}
+
+	if r.bodyNode != nil && r.bodyNode.Parent != nil && r.bodyNode.Parent.Type == html.ElementNode {
+		style = r.styleFor(r.bodyNode.Parent, style)
+	}
+
+	return style
+}
+
+func (r *HTMLRenderer) lineSpacing(value string, fontSize, inherited float32) float32 {
+	if value == "normal" {
+		return 1.2
+	}
+
+	length, ok := css.ParseLength(value)
+	if !ok {
+		return inherited
+	}
+
+	switch length.Unit {
+	case "":
+		return length.Value
+	case "%":
+		return length.Value / 100
+	}
+
+	if fontSize <= 0 {
+		return inherited
+	}
+	return r.toPx(value, fontSize) / fontSize
+}
+
+// toPx converts a CSS length to unzoomed pixels. Layout multiplies the
+// result by RenderContext.Zoom, font sizes are turned into a scale of
+// TextLIB.FontSize.
+func (r *HTMLRenderer) toPx(value string, fontSize float32) float32 {
+	switch value {
+	case "thin":
+		return 1
+	case "medium":
+		return 3
+	case "thick":
+		return 5
+	}
+
+	px, ok := css.ToPx(value, fontSize, r.rootFontSize, 0, r.styleMedia)
+	if !ok {
+		return 0
+	}
+	return px
+}
+
+func resolveEdges(px, pct EdgeSizes, zoom, base float32) EdgeSizes {
+	return EdgeSizes{
+		Top:    px.Top*zoom + pct.Top*base,
+		Right:  px.Right*zoom + pct.Right*base,
+		Bottom: px.Bottom*zoom + pct.Bottom*base,
+		Left:   px.Left*zoom + pct.Left*base,
+	}
+}
+
+func (e EdgeSizes) Scale(k float32) EdgeSizes {
+	return EdgeSizes{Top: e.Top * k, Right: e.Right * k, Bottom: e.Bottom * k, Left: e.Left * k}
+}
+
+func (d LayoutInfo) ContentBox() Rect {
+	return Rect{X: d.X, Y: d.Y, Width: d.Width, Height: d.Height}
+}
+
+func (d LayoutInfo) PaddingBox() Rect {
+	return d.ContentBox().expand(d.Padding)
+}
+
+func (d LayoutInfo) BorderBox() Rect {
+	return d.PaddingBox().expand(d.Border)
+}
+
+func (d LayoutInfo) MarginBox() Rect {
+	return d.BorderBox().expand(d.Margin)
+}
+
+func (rect Rect) expand(e EdgeSizes) Rect {
+	return Rect{
+		X:      rect.X - e.Left,
+		Y:      rect.Y - e.Top,
+		Width:  rect.Width + e.Left + e.Right,
+		Height: rect.Height + e.Top + e.Bottom,
+	}
 }
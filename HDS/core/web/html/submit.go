@@ -0,0 +1,327 @@
+package html
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FormSubmission is a request a form asks for. Body and ContentType are
+// empty for GET, the data is in the query of Action then.
+type FormSubmission struct {
+	Action      string
+	Method      string
+	ContentType string
+	Body        []byte
+}
+
+type formEntry struct {
+	name, value string
+	file        bool
+}
+
+// submit builds the submission of form for the submitter button, nil when
+// the form was submitted some other way, and hands it to FormSubmitted.
+func (r *HTMLRenderer) submit(form *html.Node, submitter *Control) {
+	if form == nil || r.FormSubmitted == nil {
+		return
+	}
+
+	attr := func(formName, name string) string {
+		if submitter != nil && submitter.Node != nil && hasAttr(submitter.Node, formName) {
+			return getAttr(submitter.Node, formName)
+		}
+		return getAttr(form, name)
+	}
+
+	action := resolveURL(r.baseURL, attr("formaction", "action"))
+	if strings.TrimSpace(attr("formaction", "action")) == "" {
+		action = r.pageURL
+	}
+	if action == "" || strings.HasPrefix(strings.ToLower(action), "javascript:") {
+		return
+	}
+
+	entries := r.formData(form, submitter)
+	submission := &FormSubmission{Action: action, Method: "GET"}
+
+	if strings.EqualFold(strings.TrimSpace(attr("formmethod", "method")), "post") {
+		submission.Method = "POST"
+		switch strings.ToLower(strings.TrimSpace(attr("formenctype", "enctype"))) {
+		case "multipart/form-data":
+			submission.ContentType, submission.Body = encodeMultipart(entries)
+		case "text/plain":
+			submission.ContentType, submission.Body = "text/plain; charset=utf-8", encodePlain(entries)
+		default:
+			submission.ContentType, submission.Body = "application/x-www-form-urlencoded", []byte(encodeURL(entries))
+		}
+	} else if u, err := url.Parse(action); err == nil {
+		u.RawQuery = encodeURL(entries)
+		submission.Action = u.String()
+	}
+
+	r.FormSubmitted(submission)
+}
+
+// formData collects the name and value pairs of the controls owned by form
+// in tree order, as in the HTML "constructing the entry list" algorithm.
+func (r *HTMLRenderer) formData(form *html.Node, submitter *Control) []formEntry {
+	var entries []formEntry
+
+	var walk func(*html.Node, bool)
+	walk = func(node *html.Node, inDatalist bool) {
+		for n := node.FirstChild; n != nil; n = n.NextSibling {
+			if n.Type != html.ElementNode {
+				continue
+			}
+			tag := strings.ToLower(n.Data)
+			if isControlElement(n) && !inDatalist && r.formOf(n) == form {
+				entries = r.appendEntries(entries, n, submitter)
+			}
+			walk(n, inDatalist || tag == "datalist")
+		}
+	}
+	walk(r.cachedDoc, false)
+
+	return entries
+}
+
+func (r *HTMLRenderer) appendEntries(entries []formEntry, node *html.Node, submitter *Control) []formEntry {
+	name := getAttr(node, "name")
+	kind := strings.ToLower(getAttr(node, "type"))
+	if hasAttr(node, "disabled") {
+		return entries
+	}
+
+	c := r.controls[node]
+	if c == nil {
+		c = newControl(node)
+	}
+
+	if c == nil {
+		// hidden inputs get no control
+		if name == "_charset_" {
+			return append(entries, formEntry{name: name, value: "UTF-8"})
+		}
+		if name != "" {
+			entries = append(entries, formEntry{name: name, value: getAttr(node, "value")})
+		}
+		return entries
+	}
+
+	if c.Disabled {
+		return entries
+	}
+
+	// no files can be picked yet, a file input sends an empty one
+	if kind == "file" && strings.ToLower(node.Data) == "input" {
+		if name != "" {
+			entries = append(entries, formEntry{name: name, file: true})
+		}
+		return entries
+	}
+
+	switch c.Kind {
+	case ButtonControl:
+		if submitter == nil || submitter.Node != node {
+			return entries
+		}
+		if kind == "image" {
+			prefix := ""
+			if name != "" {
+				prefix = name + "."
+			}
+			return append(entries, formEntry{name: prefix + "x", value: "0"}, formEntry{name: prefix + "y", value: "0"})
+		}
+		if name != "" {
+			entries = append(entries, formEntry{name: name, value: getAttr(node, "value")})
+		}
+
+	case CheckboxControl, RadioControl:
+		if c.Checked && name != "" {
+			value := "on"
+			if hasAttr(node, "value") {
+				value = getAttr(node, "value")
+			}
+			entries = append(entries, formEntry{name: name, value: value})
+		}
+
+	case SelectControl:
+		if name != "" && c.Selected >= 0 && c.Selected < len(c.Options) && !c.Options[c.Selected].Disabled {
+			entries = append(entries, formEntry{name: name, value: c.Options[c.Selected].Value})
+		}
+
+	case TextAreaControl:
+		if name != "" {
+			value := strings.ReplaceAll(strings.ReplaceAll(c.Value, "\r\n", "\n"), "\n", "\r\n")
+			entries = append(entries, formEntry{name: name, value: value})
+		}
+		if dirname := getAttr(node, "dirname"); dirname != "" {
+			entries = append(entries, formEntry{name: dirname, value: "ltr"})
+		}
+
+	default:
+		if name != "" {
+			entries = append(entries, formEntry{name: name, value: c.Value})
+		}
+		if dirname := getAttr(node, "dirname"); dirname != "" {
+			entries = append(entries, formEntry{name: dirname, value: "ltr"})
+		}
+	}
+
+	return entries
+}
+
+// encodeURL is application/x-www-form-urlencoded, keeping the entry order.
+func encodeURL(entries []formEntry) string {
+	var query strings.Builder
+	for i, entry := range entries {
+		if i > 0 {
+			query.WriteByte('&')
+		}
+		query.WriteString(url.QueryEscape(entry.name))
+		query.WriteByte('=')
+		query.WriteString(url.QueryEscape(entry.value))
+	}
+	return query.String()
+}
+
+func encodeMultipart(entries []formEntry) (string, []byte) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, entry := range entries {
+		if !entry.file {
+			writer.WriteField(entry.name, entry.value)
+			continue
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+escapeQuotes(entry.name)+`"; filename=""`)
+		header.Set("Content-Type", "application/octet-stream")
+		writer.CreatePart(header)
+	}
+	writer.Close()
+
+	return writer.FormDataContentType(), body.Bytes()
+}
+
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\"", "%22", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func encodePlain(entries []formEntry) []byte {
+	var body bytes.Buffer
+	for _, entry := range entries {
+		body.WriteString(entry.name)
+		body.WriteByte('=')
+		body.WriteString(entry.value)
+		body.WriteString("\r\n")
+	}
+	return body.Bytes()
+}
+
+// defaultButton is the first submit button of form, what Enter in one of
+// its fields presses.
+func (r *HTMLRenderer) defaultButton(form *html.Node) (*Control, bool) {
+	var found *html.Node
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for n := node.FirstChild; n != nil && found == nil; n = n.NextSibling {
+			if n.Type == html.ElementNode && isSubmitButton(n) && r.formOf(n) == form {
+				found = n
+				return
+			}
+			walk(n)
+		}
+	}
+	walk(r.cachedDoc)
+
+	if found == nil {
+		return nil, false
+	}
+	c := r.controls[found]
+	if c == nil {
+		c = newControl(found)
+	}
+	return c, true
+}
+
+func isSubmitButton(node *html.Node) bool {
+	kind := strings.ToLower(getAttr(node, "type"))
+	switch strings.ToLower(node.Data) {
+	case "button":
+		return kind == "" || kind == "submit"
+	case "input":
+		return kind == "submit" || kind == "image"
+	}
+	return false
+}
+
+// SubmitFocused is Enter in a text field: it presses the form's default
+// button, or submits a form that has no button but this one field.
+func (r *HTMLRenderer) SubmitFocused() bool {
+	c := r.focused
+	if c == nil || c.Kind != TextControl && c.Kind != PasswordControl {
+		return false
+	}
+
+	form := r.formOf(c.Node)
+	if form == nil {
+		return false
+	}
+
+	if button, ok := r.defaultButton(form); ok {
+		if !button.Disabled {
+			r.submit(form, button)
+		}
+		return true
+	}
+
+	fields := 0
+	for _, other := range r.controlOrder {
+		if (other.Kind == TextControl || other.Kind == PasswordControl) && r.formOf(other.Node) == form {
+			fields++
+		}
+	}
+	if fields == 1 {
+		r.submit(form, nil)
+	}
+	return true
+}
+
+// press is a click on a button: submit buttons submit their form and reset
+// buttons put its controls back to how the page had them.
+func (r *HTMLRenderer) press(c *Control) bool {
+	form := r.formOf(c.Node)
+	switch {
+	case isSubmitButton(c.Node):
+		r.submit(form, c)
+	case c.Type == "reset":
+		r.reset(form)
+	default:
+		return false
+	}
+	return true
+}
+
+func (r *HTMLRenderer) reset(form *html.Node) {
+	if form == nil {
+		return
+	}
+
+	for node, c := range r.controls {
+		if r.formOf(node) != form {
+			continue
+		}
+		if fresh := newControl(node); fresh != nil {
+			fresh.box = c.box
+			*c = *fresh
+		}
+	}
+	r.openSelect = nil
+}
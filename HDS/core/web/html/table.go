@@ -0,0 +1,428 @@
+package html
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tableCell struct {
+	box              *LayoutBox
+	row, col         int
+	colspan, rowspan int
+}
+
+type tableGrid struct {
+	rows  []*LayoutBox
+	cells []tableCell
+	cols  int
+}
+
+func isRowGroup(display string) bool {
+	switch display {
+	case "table-row-group", "table-header-group", "table-footer-group":
+		return true
+	}
+	return false
+}
+
+// buildTable orders the children of a table as captions, then the rows of
+// the header groups, the body and the footer groups. Cells placed straight
+// in the table or a group get an anonymous row.
+func buildTable(box *LayoutBox, children []*LayoutBox) {
+	var captions, head, body, foot []*LayoutBox
+
+	addRows := func(rows *[]*LayoutBox, boxes []*LayoutBox) {
+		var anonymous *LayoutBox
+		for _, child := range boxes {
+			switch child.Type {
+			case TableRowBox:
+				*rows = append(*rows, child)
+				anonymous = nil
+			case TableCellBox:
+				if anonymous == nil {
+					anonymous = &LayoutBox{Type: TableRowBox, Node: box.Node, Style: box.Style}
+					*rows = append(*rows, anonymous)
+				}
+				anonymous.Children = append(anonymous.Children, child)
+			}
+		}
+	}
+
+	var loose []*LayoutBox
+	for _, child := range children {
+		switch {
+		case child.Style.Display == "table-caption":
+			captions = append(captions, child)
+		case child.Style.Display == "table-header-group":
+			addRows(&head, child.Children)
+		case child.Style.Display == "table-footer-group":
+			addRows(&foot, child.Children)
+		case isRowGroup(child.Style.Display):
+			addRows(&body, child.Children)
+		default:
+			loose = append(loose, child)
+		}
+	}
+	addRows(&body, loose)
+
+	box.Children = append(append(append(captions, head...), body...), foot...)
+}
+
+func buildRow(box *LayoutBox, children []*LayoutBox) {
+	for _, child := range children {
+		if child.Type == TableCellBox {
+			box.Children = append(box.Children, child)
+		}
+	}
+}
+
+func spanAttr(box *LayoutBox, name string, limit int) int {
+	span, err := strconv.Atoi(strings.TrimSpace(getAttr(box.Node, name)))
+	if err != nil || span < 0 {
+		return 1
+	}
+	return min(span, limit)
+}
+
+// tableGridOf places every cell on the grid, skipping slots taken by row and
+// column spans from above.
+func tableGridOf(box *LayoutBox) *tableGrid {
+	grid := &tableGrid{}
+	for _, child := range box.Children {
+		if child.Type == TableRowBox {
+			grid.rows = append(grid.rows, child)
+		}
+	}
+
+	occupied := make([]map[int]bool, len(grid.rows))
+	for i := range occupied {
+		occupied[i] = make(map[int]bool)
+	}
+
+	for ri, row := range grid.rows {
+		col := 0
+		for _, cell := range row.Children {
+			for occupied[ri][col] {
+				col++
+			}
+
+			colspan := max(spanAttr(cell, "colspan", 1000), 1)
+			rowspan := spanAttr(cell, "rowspan", 65534)
+			if rowspan == 0 || ri+rowspan > len(grid.rows) {
+				rowspan = len(grid.rows) - ri
+			}
+
+			for r := ri; r < ri+rowspan; r++ {
+				for c := col; c < col+colspan; c++ {
+					occupied[r][c] = true
+				}
+			}
+
+			grid.cells = append(grid.cells, tableCell{box: cell, row: ri, col: col, colspan: colspan, rowspan: rowspan})
+			col += colspan
+			grid.cols = max(grid.cols, col)
+		}
+	}
+
+	return grid
+}
+
+// columnWidths computes the min-content and max-content width of every
+// column. Spanning cells spread what the columns lack evenly.
+func (r *HTMLRenderer) columnWidths(ctx *RenderContext, grid *tableGrid, spacing float32) ([]float32, []float32) {
+	mins := make([]float32, grid.cols)
+	maxs := make([]float32, grid.cols)
+
+	cellWidths := func(cell tableCell) (float32, float32) {
+		minW, maxW := r.contentWidths(ctx, cell.box)
+		if width, pct, ok := r.specifiedLength(cell.box, "width"); ok && !pct {
+			maxW = max(minW, width*ctx.Zoom)
+		}
+		return minW, max(minW, maxW)
+	}
+
+	for _, cell := range grid.cells {
+		if cell.colspan == 1 {
+			minW, maxW := cellWidths(cell)
+			mins[cell.col] = max(mins[cell.col], minW)
+			maxs[cell.col] = max(maxs[cell.col], maxW)
+		}
+	}
+
+	for _, cell := range grid.cells {
+		if cell.colspan == 1 {
+			continue
+		}
+
+		minW, maxW := cellWidths(cell)
+		gaps := spacing * float32(cell.colspan-1)
+		var curMin, curMax float32
+		for c := cell.col; c < cell.col+cell.colspan; c++ {
+			curMin += mins[c]
+			curMax += maxs[c]
+		}
+
+		for c := cell.col; c < cell.col+cell.colspan; c++ {
+			if extra := minW - gaps - curMin; extra > 0 {
+				mins[c] += extra / float32(cell.colspan)
+			}
+			if extra := maxW - gaps - curMax; extra > 0 {
+				maxs[c] += extra / float32(cell.colspan)
+			}
+		}
+	}
+
+	for c := range maxs {
+		maxs[c] = max(maxs[c], mins[c])
+	}
+
+	return mins, maxs
+}
+
+// distributeColumns picks the used column widths for the width the table
+// may take. Without a specified width the table shrinks to its max-content.
+func distributeColumns(mins, maxs []float32, available float32, fixed bool) []float32 {
+	var sumMin, sumMax float32
+	for c := range mins {
+		sumMin += mins[c]
+		sumMax += maxs[c]
+	}
+
+	widths := make([]float32, len(mins))
+	switch {
+	case sumMin >= available:
+		copy(widths, mins)
+	case sumMax <= available:
+		copy(widths, maxs)
+		if fixed {
+			extra := available - sumMax
+			for c := range widths {
+				if sumMax > 0 {
+					widths[c] += extra * maxs[c] / sumMax
+				} else {
+					widths[c] += extra / float32(len(widths))
+				}
+			}
+		}
+	default:
+		ratio := (available - sumMin) / (sumMax - sumMin)
+		for c := range widths {
+			widths[c] = mins[c] + (maxs[c]-mins[c])*ratio
+		}
+	}
+
+	return widths
+}
+
+func (r *HTMLRenderer) layoutTable(ctx *RenderContext, box *LayoutBox, containing *LayoutInfo) {
+	d := &box.Dimensions
+	d.Margin = resolveEdges(box.Style.Margin, box.Style.MarginPct, ctx.Zoom, containing.Width)
+	d.Border = box.Style.Border.Scale(ctx.Zoom)
+	d.Padding = resolveEdges(box.Style.Padding, box.Style.PaddingPct, ctx.Zoom, containing.Width)
+
+	edges := d.Border.Left + d.Border.Right + d.Padding.Left + d.Padding.Right
+	available := containing.Width - d.Margin.Left - d.Margin.Right - edges
+
+	d.X = containing.X + d.Margin.Left + d.Border.Left + d.Padding.Left
+	d.Y = containing.Y + containing.Height + d.Margin.Top + d.Border.Top + d.Padding.Top
+	d.Height = 0
+	d.LineHeight = r.lineHeight(ctx, box.Style)
+
+	spacing := box.Style.BorderSpacing * ctx.Zoom
+	grid := tableGridOf(box)
+	mins, maxs := r.columnWidths(ctx, grid, spacing)
+
+	gaps := spacing * float32(grid.cols+1)
+	target, fixed := available, false
+	if width, pct, ok := r.specifiedLength(box, "width"); ok {
+		if pct {
+			target = available * width / 100
+		} else {
+			target = width*ctx.Zoom - edges
+		}
+		fixed = true
+	}
+	widths := distributeColumns(mins, maxs, max(target-gaps, 0), fixed)
+
+	d.Width = gaps
+	for _, w := range widths {
+		d.Width += w
+	}
+	if grid.cols == 0 {
+		d.Width = max(target, 0)
+	}
+
+	for _, child := range box.Children {
+		if child.Type != TableRowBox {
+			r.layoutBlock(ctx, child, d)
+			d.Height += child.Dimensions.MarginBox().Height
+		}
+	}
+
+	colX := make([]float32, grid.cols+1)
+	colX[0] = d.X + spacing
+	for c, w := range widths {
+		colX[c+1] = colX[c] + w + spacing
+	}
+
+	rowsTop := d.Y + d.Height + spacing
+	rowHeights := make([]float32, len(grid.rows))
+
+	for _, cell := range grid.cells {
+		width := colX[cell.col+cell.colspan] - colX[cell.col] - spacing
+		r.layoutBlock(ctx, cell.box, &LayoutInfo{X: colX[cell.col], Y: rowsTop, Width: width})
+		if cell.rowspan == 1 {
+			rowHeights[cell.row] = max(rowHeights[cell.row], cell.box.Dimensions.BorderBox().Height)
+		}
+	}
+
+	spanHeight := func(cell tableCell) float32 {
+		height := spacing * float32(cell.rowspan-1)
+		for row := cell.row; row < cell.row+cell.rowspan; row++ {
+			height += rowHeights[row]
+		}
+		return height
+	}
+
+	for _, cell := range grid.cells {
+		if extra := cell.box.Dimensions.BorderBox().Height - spanHeight(cell); cell.rowspan > 1 && extra > 0 {
+			rowHeights[cell.row+cell.rowspan-1] += extra
+		}
+	}
+
+	rowY := make([]float32, len(grid.rows))
+	y := rowsTop
+	for i, row := range grid.rows {
+		rowY[i] = y
+		row.Dimensions = LayoutInfo{X: d.X, Y: y, Width: d.Width, Height: rowHeights[i], LineHeight: d.LineHeight}
+		y += rowHeights[i] + spacing
+	}
+
+	for _, cell := range grid.cells {
+		cd := &cell.box.Dimensions
+		extra := spanHeight(cell) - cd.BorderBox().Height
+
+		offset := float32(0)
+		switch cell.box.Style.VerticalAlign {
+		case "middle":
+			offset = extra / 2
+		case "bottom":
+			offset = extra
+		}
+
+		shiftBox(cell.box, rowY[cell.row]-rowsTop+offset)
+		cd.Y -= offset
+		cd.Height += extra
+	}
+
+	if len(grid.rows) > 0 {
+		d.Height = y - d.Y
+	}
+	if box.Node != nil {
+		r.layoutCache[box.Node] = d
+	}
+}
+
+// shiftBox moves a laid out box and everything in it down by dy.
+func shiftBox(box *LayoutBox, dy float32) {
+	if dy == 0 {
+		return
+	}
+
+	box.Dimensions.Y += dy
+	for i := range box.Lines {
+		box.Lines[i].Y += dy
+		box.Lines[i].Baseline += dy
+	}
+	for _, child := range box.Children {
+		shiftBox(child, dy)
+	}
+}
+
+// contentWidths returns the min-content and max-content border-box width of
+// a box that was built but not laid out yet.
+func (r *HTMLRenderer) contentWidths(ctx *RenderContext, box *LayoutBox) (float32, float32) {
+	var minW, maxW float32
+
+	switch {
+	case box.Type == AnonymousBox:
+		minW, maxW = r.inlineWidths(ctx, box.Children)
+
+	case box.atomic():
+		minW, _ = r.atomicSize(ctx, box)
+		maxW = minW
+
+	case box.Type == TableBox:
+		spacing := box.Style.BorderSpacing * ctx.Zoom
+		grid := tableGridOf(box)
+		mins, maxs := r.columnWidths(ctx, grid, spacing)
+		minW = spacing * float32(grid.cols+1)
+		maxW = minW
+		for c := range mins {
+			minW += mins[c]
+			maxW += maxs[c]
+		}
+
+	case box.Type == InlineBox || box.Type == TextBox:
+		minW, maxW = r.inlineWidths(ctx, []*LayoutBox{box})
+
+	default:
+		for _, child := range box.Children {
+			childMin, childMax := r.contentWidths(ctx, child)
+			minW = max(minW, childMin)
+			maxW = max(maxW, childMax)
+		}
+	}
+
+	margin := resolveEdges(box.Style.Margin, box.Style.MarginPct, ctx.Zoom, 0)
+	border := box.Style.Border.Scale(ctx.Zoom)
+	padding := resolveEdges(box.Style.Padding, box.Style.PaddingPct, ctx.Zoom, 0)
+	edges := margin.Left + margin.Right + border.Left + border.Right + padding.Left + padding.Right
+	if box.Type == AnonymousBox || box.Type == TextBox {
+		edges = 0
+	}
+
+	return minW + edges, maxW + edges
+}
+
+// inlineWidths is the widest unbreakable item and the longest line of an
+// inline run laid out without wrapping.
+func (r *HTMLRenderer) inlineWidths(ctx *RenderContext, boxes []*LayoutBox) (float32, float32) {
+	var f inlineFlattener
+	for _, box := range boxes {
+		f.add(box)
+	}
+
+	var minW, maxW, line, run float32
+	for _, item := range f.items {
+		if item.br {
+			maxW = max(maxW, line)
+			line, run = 0, 0
+			continue
+		}
+
+		scale := item.box.Style.FontScale * ctx.Zoom
+		var width float32
+		if item.atomic {
+			width, _ = r.atomicSize(ctx, item.box)
+		} else {
+			width = r.measureText(item.box.Style, item.word, scale)
+		}
+		space := float32(0)
+		if item.space && line > 0 {
+			space = r.measureText(item.box.Style, " ", scale)
+		}
+
+		// items that cannot break apart count as one for min-content
+		if item.noBreak {
+			run += space + width
+		} else {
+			run = width
+		}
+
+		minW = max(minW, run)
+		line += space + width
+	}
+
+	return minW, max(maxW, line)
+}
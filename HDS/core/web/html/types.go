@@ -1,41 +1,108 @@
 package html
 
-import "golang.org/x/net/html"
-
-type HTMLConfig struct {
-	TextColor    [3]float32
-	LinkColor    [3]float32
-	HeadingColor [3]float32
-
-	H1Size    float32
-	H2Size    float32
-	H3Size    float32
-	H4Size    float32
-	H5Size    float32
-	H6Size    float32
-	BaseSize  float32
-	SmallSize float32
-
-	ParagraphSpacing float32
-	LineSpacing      float32
-	IndentSize       float32
-
-	H1MarginTop    float32
-	H1MarginBottom float32
-	H2MarginTop    float32
-	H2MarginBottom float32
-	H3MarginTop    float32
-	H3MarginBottom float32
+import (
+	"context"
+
+	"github.com/RDLxxx/Himera/HDS/core/web/css"
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+	"golang.org/x/net/html"
+)
+
+type EdgeSizes struct {
+	Top, Right, Bottom, Left float32
+}
+
+type Rect struct {
+	X, Y          float32
+	Width, Height float32
 }
 
+// LayoutInfo is the box model of a laid out box. X, Y, Width and Height
+// describe the content box, the edges grow outwards from it.
 type LayoutInfo struct {
 	X, Y          float32
 	Width, Height float32
 	LineHeight    float32
+
+	Margin  EdgeSizes
+	Border  EdgeSizes
+	Padding EdgeSizes
+}
+
+type BoxType int
+
+const (
+	BlockBox BoxType = iota
+	InlineBox
+	AnonymousBox
+	TextBox
+	TableBox
+	TableRowBox
+	TableCellBox
+)
+
+type BoxStyle struct {
+	Display     string
+	Visible     bool
+	Color       [3]float32
+	FontScale   float32
+	LineSpacing float32
+	TextAlign   string
+	Font        TextLIB.FaceKey
+	WhiteSpace  string
+
+	Background    [3]float32
+	HasBackground bool
+
+	// Margin and Padding are in unzoomed pixels, the Pct edges are
+	// fractions of the containing block width added on top at layout time.
+	Margin     EdgeSizes
+	MarginPct  EdgeSizes
+	Border     EdgeSizes
+	Padding    EdgeSizes
+	PaddingPct EdgeSizes
+
+	// BorderColor is top, right, bottom, left.
+	BorderColor   [4][3]float32
+	BorderSpacing float32
+	VerticalAlign string
+}
+
+type TextLine struct {
+	Text     string
+	X, Y     float32
+	Width    float32
+	Height   float32
+	Baseline float32
+}
+
+type LayoutBox struct {
+	Type       BoxType
+	Node       *html.Node
+	Style      *BoxStyle
+	Dimensions LayoutInfo
+	Children   []*LayoutBox
+
+	Text   string
+	Lines  []TextLine
+	Marker string
+
+	// Image is the resolved source of an <img>, laid out as one atomic box.
+	Image string
+
+	// Control is the widget of a form element, also an atomic box.
+	Control *Control
+}
+
+// atomic reports whether the box is laid out as a whole, like an image.
+func (box *LayoutBox) atomic() bool {
+	return box.Image != "" || box.Control != nil
 }
 
 type RenderContext struct {
 	Program      uint32
+	RectProgram  uint32
+	ImageProgram uint32
 	X, Y         float32
 	Width        float32
 	Height       float32
@@ -49,8 +116,45 @@ type HTMLRenderer struct {
 	bodyNode    *html.Node
 	parsed      bool
 
-	HTMLstyle *HTMLConfig
+	pageURL   string
+	baseURL   string
+	userAgent string
+
+	stylesheets []*css.Stylesheet
+	styles      map[*html.Node]*css.Style
+	styleMedia  css.Media
+
+	rootFontSize float32
 
-	textCache   map[*html.Node]string
 	layoutCache map[*html.Node]*LayoutInfo
+	layoutRoot  *LayoutBox
+	layoutKey   layoutKey
+
+	images *imageStore
+
+	// controls keeps the state of the form widgets across layouts,
+	// controlOrder lists the ones in the current layout in document order.
+	controls     map[*html.Node]*Control
+	controlOrder []*Control
+	focused      *Control
+	openSelect   *Control
+
+	// ctx is cancelled by Release and stops the image loaders, fetchCtx
+	// is what stylesheet requests run with.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	fetchCtx context.Context
+
+	// ResourceLoaded is called from a loader goroutine when an image of
+	// the page is ready.
+	ResourceLoaded func()
+
+	// FormSubmitted is called when a form of the page is submitted.
+	FormSubmitted func(*FormSubmission)
+}
+
+// layoutKey is the viewport and resource state a cached layout was computed for.
+type layoutKey struct {
+	x, y, width, height, zoom float32
+	resources                 int64
 }
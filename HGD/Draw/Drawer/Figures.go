@@ -1,14 +1,16 @@
 package drawer
 
 import (
-	"github.com/RDLxxx/Himera/HGD/core"
-
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 type GLResources struct {
-	rectVAO, rectVBO uint32
-	initialized      bool
+	rectVAO, rectVBO   uint32
+	imageVAO, imageVBO uint32
+	initialized        bool
+
+	viewportWidth, viewportHeight float32
 }
 
 var glResources = &GLResources{}
@@ -20,6 +22,8 @@ func InitGLResources() {
 
 	gl.GenVertexArrays(1, &glResources.rectVAO)
 	gl.GenBuffers(1, &glResources.rectVBO)
+	gl.GenVertexArrays(1, &glResources.imageVAO)
+	gl.GenBuffers(1, &glResources.imageVBO)
 
 	glResources.initialized = true
 }
@@ -28,15 +32,25 @@ func CleanupGLResources() {
 	if glResources.initialized {
 		gl.DeleteVertexArrays(1, &glResources.rectVAO)
 		gl.DeleteBuffers(1, &glResources.rectVBO)
+		gl.DeleteVertexArrays(1, &glResources.imageVAO)
+		gl.DeleteBuffers(1, &glResources.imageVBO)
 		glResources.initialized = false
 	}
 }
 
+func SetViewport(width, height int) {
+	glResources.viewportWidth = float32(width)
+	glResources.viewportHeight = float32(height)
+}
+
 func DrawRect(program uint32, x, y, width, height float32, color [3]float32) {
 	if !glResources.initialized {
 		InitGLResources()
 	}
 
+	// text queued before the rect has to stay underneath it
+	TextLIB.Flush()
+
 	vertices := []float32{
 		x, y,
 		x, y + height,
@@ -55,16 +69,7 @@ func DrawRect(program uint32, x, y, width, height float32, color [3]float32) {
 
 	gl.UseProgram(program)
 
-	projectionLoc := gl.GetUniformLocation(program, gl.Str("projection\x00"))
-	if projectionLoc >= 0 {
-		projection := [16]float32{
-			2.0 / float32(core.Browse.CurrentWidth), 0, 0, 0,
-			0, -2.0 / float32(core.Browse.CurrentHeight), 0, 0,
-			0, 0, -1, 0,
-			-1, 1, 0, 1,
-		}
-		gl.UniformMatrix4fv(projectionLoc, 1, false, &projection[0])
-	}
+	setProjection(program)
 
 	colorLoc := gl.GetUniformLocation(program, gl.Str("fillColor\x00"))
 	if colorLoc >= 0 {
@@ -77,3 +82,16 @@ func DrawRect(program uint32, x, y, width, height float32, color [3]float32) {
 
 	gl.BindVertexArray(0)
 }
+
+func setProjection(program uint32) {
+	projectionLoc := gl.GetUniformLocation(program, gl.Str("projection\x00"))
+	if projectionLoc >= 0 {
+		projection := [16]float32{
+			2.0 / glResources.viewportWidth, 0, 0, 0,
+			0, -2.0 / glResources.viewportHeight, 0, 0,
+			0, 0, -1, 0,
+			-1, 1, 0, 1,
+		}
+		gl.UniformMatrix4fv(projectionLoc, 1, false, &projection[0])
+	}
+}
@@ -0,0 +1,80 @@
+package drawer
+
+import (
+	"image"
+
+	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// NewTexture uploads a decoded image and returns the texture to draw it with.
+func NewTexture(img *image.NRGBA) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(img.Stride/4))
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		gl.RGBA,
+		int32(img.Rect.Dx()),
+		int32(img.Rect.Dy()),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(img.Pix),
+	)
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	return texture
+}
+
+func DeleteTexture(texture uint32) {
+	if texture != 0 {
+		gl.DeleteTextures(1, &texture)
+	}
+}
+
+func DrawImage(program, texture uint32, x, y, width, height float32) {
+	if !glResources.initialized {
+		InitGLResources()
+	}
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	TextLIB.Flush()
+
+	vertices := []float32{
+		x, y, 0, 0,
+		x, y + height, 0, 1,
+		x + width, y + height, 1, 1,
+		x, y, 0, 0,
+		x + width, y + height, 1, 1,
+		x + width, y, 1, 0,
+	}
+
+	gl.BindVertexArray(glResources.imageVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, glResources.imageVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STREAM_DRAW)
+
+	gl.VertexAttribPointer(0, 4, gl.FLOAT, false, 4*4, nil)
+	gl.EnableVertexAttribArray(0)
+
+	gl.UseProgram(program)
+	setProjection(program)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.BindVertexArray(0)
+}
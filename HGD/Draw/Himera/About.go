@@ -0,0 +1,104 @@
+package himera
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	h "github.com/RDLxxx/Himera/HDS/core/http"
+)
+
+// aboutPage builds the browser's own about: pages.
+func aboutPage(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "about" {
+		return "", false
+	}
+
+	switch u.Opaque {
+	case "cookies":
+		return cookiesPage(u.Query()), true
+	}
+	return "", false
+}
+
+// aboutAction does what an about: link asks of the browser, and returns
+// the page to show after it. Links that only show a page return false.
+func aboutAction(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "about" {
+		return "", false
+	}
+
+	switch u.Opaque {
+	case "cookies":
+		// ?clear= removes the cookies of a site, "*" clears them all
+		site := u.Query().Get("clear")
+		if site == "" {
+			return "", false
+		}
+		if site == "*" {
+			site = ""
+		}
+		h.Cookies.Clear(site)
+		return "about:cookies", true
+	}
+	return "", false
+}
+
+// cookiesPage lists the stored cookies per site, ?site= shows one site.
+func cookiesPage(query url.Values) string {
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html><html><head><title>Cookies</title></head><body><h1>Cookies</h1>")
+
+	if site := query.Get("site"); site != "" {
+		page.WriteString("<h2>" + html.EscapeString(site) + "</h2>")
+		page.WriteString(`<p><a href="about:cookies">All sites</a> | <a href="about:cookies?clear=` + url.QueryEscape(site) + `">Clear</a></p>`)
+		page.WriteString("<table border><tr><th>Name</th><th>Value</th><th>Domain</th><th>Path</th><th>Expires</th><th>Flags</th></tr>")
+
+		for _, c := range h.Cookies.SiteCookies(site) {
+			expires := "Session"
+			if c.Persistent() {
+				expires = c.Expires.Local().Format(time.DateTime)
+			}
+
+			var flags []string
+			if c.HostOnly {
+				flags = append(flags, "HostOnly")
+			}
+			if c.Secure {
+				flags = append(flags, "Secure")
+			}
+			if c.HttpOnly {
+				flags = append(flags, "HttpOnly")
+			}
+			if c.SameSite != "" {
+				flags = append(flags, "SameSite="+c.SameSite)
+			}
+
+			page.WriteString("<tr>")
+			for _, cell := range []string{c.Name, c.Value, c.Domain, c.Path, expires, strings.Join(flags, " ")} {
+				page.WriteString("<td>" + html.EscapeString(cell) + "</td>")
+			}
+			page.WriteString("</tr>")
+		}
+		page.WriteString("</table>")
+	} else {
+		sites := h.Cookies.Sites()
+		if len(sites) == 0 {
+			page.WriteString("<p>No cookies stored.</p>")
+		} else {
+			page.WriteString(`<p><a href="about:cookies?clear=*">Clear all</a></p><ul>`)
+			for _, site := range sites {
+				page.WriteString(`<li><a href="about:cookies?site=` + url.QueryEscape(site) + `">` + html.EscapeString(site) + `</a> ` +
+					`(` + strconv.Itoa(len(h.Cookies.SiteCookies(site))) + `) <a href="about:cookies?clear=` + url.QueryEscape(site) + `">clear</a></li>`)
+			}
+			page.WriteString("</ul>")
+		}
+	}
+
+	page.WriteString("</body></html>")
+	return page.String()
+}
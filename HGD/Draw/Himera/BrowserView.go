@@ -1,66 +1,203 @@
 package himera
 
 import (
-	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 
 	h "github.com/RDLxxx/Himera/HDS/core/http"
 	web "github.com/RDLxxx/Himera/HDS/core/web/html"
 	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
+	"github.com/RDLxxx/Himera/HGD/browser"
 	"github.com/RDLxxx/Himera/HGD/core"
 	"github.com/RDLxxx/Himera/HGD/utils"
-	"golang.org/x/net/html"
 )
 
-func RenderHTML(program uint32) {
+func RenderHTML(program, rectProgram, imageProgram uint32) {
 	if core.Browse.HtmlRenderer == nil {
 		return
 	}
 
-	availableHeight := float32(core.Browse.CurrentHeight) - core.Browse.InputBoxHeight - 20.0
-	ctx := &web.RenderContext{
-		Program:      program,
-		X:            10.0 * core.Browse.Zoom,
-		Y:            core.Browse.InputBoxHeight + 15.0*core.Browse.Zoom,
-		Width:        float32(core.Browse.CurrentWidth) - 20.0*core.Browse.Zoom,
-		Height:       availableHeight,
-		ScrollOffset: core.Browse.ScrollOffset,
-		Zoom:         core.Browse.Zoom,
-	}
+	ctx := pageContext()
+	ctx.Program = program
+	ctx.RectProgram = rectProgram
+	ctx.ImageProgram = imageProgram
+	ctx.ScrollOffset = core.Browse.ScrollOffset
 
 	if err := core.Browse.HtmlRenderer.Render(ctx); err != nil {
 		TextLIB.DrawText(program, "HTML Render Error: "+err.Error(),
-			10.0*core.Browse.Zoom, core.Browse.InputBoxHeight+15.0*core.Browse.Zoom, core.Browse.Zoom, utils.RGBToFloat32(255, 100, 100))
+			10.0*core.Browse.Zoom, core.Browse.ChromeHeight()+15.0*core.Browse.Zoom, core.Browse.Zoom, utils.RGBToFloat32(255, 100, 100))
 	}
 }
 
-func UpdateContent(link string, ua string) web.HTMLRenderer {
-	req, err := h.GETRequest(link, ua)
-	if err != nil {
-		errorHTML := `
-						<!DOCTYPE html>
-						<html>
-							<head>
-								<title>Error</title>
-							</head>
-							<body>
-								<h1>Failed to load page</h1>
-								<p>Error: ` + err.Error() + `</p>
-								<p>Please check your internet connection and try again.</p>
-							</body>
-						</html>
-					`
-		core.Browse.HtmlRenderer = web.NewHTMLRenderer(errorHTML)
-	} else {
-		core.Browse.HtmlRenderer = web.NewHTMLRenderer(req.Page)
-		doc := web.ParseHTML(req.Page)
-
-		fmt.Println("\n\n\n\n")
-		// fmt.Println(doc.FirstChild.Parent)
-		if doc.Type == html.ElementNode && doc.Data == "h1" {
-			if doc.FirstChild != nil {
-				fmt.Println(doc.FirstChild.Data)
+// pageContext is the viewport the page is laid out in. Painting, scroll
+// limits and hit-testing must all use it so they share one cached layout.
+func pageContext() *web.RenderContext {
+	return &web.RenderContext{
+		X:      10.0 * core.Browse.Zoom,
+		Y:      core.Browse.ChromeHeight() + 15.0*core.Browse.Zoom,
+		Width:  float32(core.Browse.CurrentWidth) - 20.0*core.Browse.Zoom,
+		Height: float32(core.Browse.CurrentHeight) - core.Browse.ChromeHeight() - 20.0,
+		Zoom:   core.Browse.Zoom,
+	}
+}
+
+// Navigate opens link in the view and records it in the history, a link
+// into the current document only scrolls to its fragment.
+func Navigate(link string) {
+	navigate(link, "")
+}
+
+// FollowLink navigates to a link of the page shown.
+func FollowLink(link string) {
+	navigate(link, pageLink())
+}
+
+// navigate is Navigate for a link the page at initiator started, an empty
+// initiator is the user.
+func navigate(link, initiator string) {
+	if link == retryLink {
+		Reload()
+		return
+	}
+	link = addressURL(link)
+	if !allowNavigation(link, initiator) {
+		return
+	}
+	if next, ok := aboutAction(link); ok {
+		link = next
+	}
+
+	current, _ := web.SplitFragment(core.Browse.Link)
+	target, fragment := web.SplitFragment(link)
+
+	saveHistoryState()
+	previous := core.Browse.History.Current()
+	setLink(link)
+
+	if target == current && fragment != "" && core.Browse.HtmlRenderer != nil && !core.Browse.Loading {
+		scrollToFragment(fragment)
+		entry := browser.HistoryEntry{
+			URL:          link,
+			Title:        core.Browse.HtmlRenderer.Title(),
+			ScrollOffset: core.Browse.ScrollOffset,
+			Zoom:         core.Browse.Zoom,
+		}
+		if previous != nil {
+			entry.Page = previous.Page
+		}
+		core.Browse.History.Push(entry)
+		MarkNeedsRedraw()
+		return
+	}
+
+	open(link, initiator, nil)
+}
+
+// Submit sends a form submission and shows the response like any other
+// page. A GET submission is a plain navigation to its URL.
+func Submit(form *web.FormSubmission, initiator string) {
+	if form.Method != "POST" {
+		navigate(form.Action, initiator)
+		return
+	}
+	if !allowNavigation(form.Action, initiator) {
+		return
+	}
+
+	saveHistoryState()
+	setLink(form.Action)
+	open(form.Action, initiator, form)
+}
+
+// open pushes a history entry for link and loads it.
+func open(link, initiator string, form *web.FormSubmission) {
+	_, fragment := web.SplitFragment(link)
+
+	core.Browse.History.Push(browser.HistoryEntry{URL: link, Zoom: core.Browse.Zoom, Form: form})
+	index := core.Browse.History.Index
+	loadPage(link, initiator, form, "", false, func(err error) {
+		scrollToFragment(fragment)
+		if entry := core.Browse.History.Current(); entry != nil && core.Browse.History.Index == index {
+			entry.Title = core.Browse.HtmlRenderer.Title()
+			if err == nil {
+				entry.Page = core.Browse.HtmlRenderer.Source()
 			}
 		}
+	})
+	MarkNeedsRedraw()
+}
+
+// scrollToFragment scrolls to the element fragment names, or to the top.
+func scrollToFragment(fragment string) {
+	core.Browse.ScrollOffset = 0
+	if offset, ok := core.Browse.HtmlRenderer.AnchorOffset(pageContext(), fragment); ok {
+		core.Browse.ScrollOffset = -offset
+	}
+	UpdateScrollLimits()
+}
+
+// addressURL is the link for what was typed into the URL box, absolute
+// local paths become file:// URLs.
+func addressURL(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			text = filepath.Join(home, text[2:])
+		}
+	}
+	if filepath.IsAbs(text) {
+		return h.FileURL(text)
+	}
+	return text
+}
+
+func setLink(link string) {
+	core.Browse.Link = link
+	core.Browse.InputText = link
+	core.Browse.CursorPosition = len(link)
+	core.Browse.HoverLink = ""
+}
+
+// allowNavigation tells whether the page at initiator may open link. Only
+// the browser's own pages may open them, and pages from the web can't open
+// local files.
+func allowNavigation(link, initiator string) bool {
+	from, err := url.Parse(initiator)
+	if initiator == "" || err == nil && from.Scheme == "about" {
+		return true
+	}
+	to, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	switch to.Scheme {
+	case "about":
+		return false
+	case "file":
+		return from.Scheme != "http" && from.Scheme != "https"
+	}
+	return true
+}
+
+// pageLink is the URL of the page shown, what its links and forms are
+// followed from.
+func pageLink() string {
+	if core.Browse.HtmlRenderer == nil {
+		return ""
 	}
-	return *core.Browse.HtmlRenderer
+	return core.Browse.HtmlRenderer.URL()
+}
+
+// LinkAt is the link under the window point x, y.
+func LinkAt(x, y float32) string {
+	if core.Browse.HtmlRenderer == nil || y < core.Browse.ChromeHeight() {
+		return ""
+	}
+
+	ctx := pageContext()
+	ctx.ScrollOffset = core.Browse.ScrollOffset
+	return core.Browse.HtmlRenderer.LinkAt(ctx, x, y)
 }
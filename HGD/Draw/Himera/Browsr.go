@@ -1,33 +1,54 @@
 package himera
 
 import (
+	"math"
+
 	drawer "github.com/RDLxxx/Himera/HGD/Draw/Drawer"
 	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
 	"github.com/RDLxxx/Himera/HGD/core"
 	"github.com/RDLxxx/Himera/HGD/utils"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 func DrawURLBox(rectProgram uint32, textProgram uint32) {
 	inputBoxWidth := float32(core.Browse.CurrentWidth)
+	top := core.Browse.TabBarHeight
 
-	drawer.DrawRect(rectProgram, 0, 0, inputBoxWidth, core.Browse.InputBoxHeight,
+	drawer.DrawRect(rectProgram, 0, top, inputBoxWidth, core.Browse.InputBoxHeight,
 		utils.RGBToFloat32(200, 200, 200))
-	drawer.DrawRect(rectProgram, 0, 0+core.Browse.InputBoxHeight-2.0, inputBoxWidth, 2.0, utils.RGBToFloat32(0, 0, 0))
+	drawer.DrawRect(rectProgram, 0, top+core.Browse.InputBoxHeight-2.0, inputBoxWidth, 2.0, utils.RGBToFloat32(0, 0, 0))
+
+	if core.Browse.Loading {
+		// a segment sweeping along the bottom edge while the page loads
+		segment := inputBoxWidth / 5
+		x := float32(math.Mod(glfw.GetTime()*0.6, 1.0))*(inputBoxWidth+segment) - segment
+		drawer.DrawRect(rectProgram, x, top+core.Browse.InputBoxHeight-3.0, segment, 3.0, utils.RGBToFloat32(100, 149, 237))
+	}
 
 	gl.UseProgram(textProgram)
 
-	textY := 0 + core.Browse.InputBoxHeight/2 - TextLIB.GetLineHeight(1.0)/2 + TextLIB.GetFontAscent(1.0)
+	textY := top + core.Browse.InputBoxHeight/2 - TextLIB.GetLineHeight(1.0)/2 + TextLIB.GetFontAscent(1.0)
 	TextLIB.DrawText(textProgram, core.Browse.InputText, 0, textY, 1.0,
 		utils.RGBToFloat32(0, 0, 0))
 
+	// the failure of the last load, kept in view at the right end
+	if status := core.Browse.Status; status != "" && !core.Browse.Loading {
+		statusWidth, _ := TextLIB.GetTextDimensions(status, 0.8)
+		x := inputBoxWidth - statusWidth - 22.0
+		drawer.DrawRect(rectProgram, x, top+5.0, statusWidth+16.0, core.Browse.InputBoxHeight-10.0, utils.RGBToFloat32(190, 50, 50))
+		gl.UseProgram(textProgram)
+		statusY := top + core.Browse.InputBoxHeight/2 - TextLIB.GetLineHeight(0.8)/2 + TextLIB.GetFontAscent(0.8)
+		TextLIB.DrawText(textProgram, status, x+8.0, statusY, 0.8, utils.RGBToFloat32(255, 255, 255))
+	}
+
 	if core.Browse.InputBoxFocused {
 		core.Browse.BlinkTimer += 16.0
 		if int(core.Browse.BlinkTimer/500)%2 == 0 {
 			cursorText := core.Browse.InputText[:core.Browse.CursorPosition]
 			cursorX, _ := TextLIB.GetTextDimensions(cursorText, 1.0)
-			drawer.DrawRect(rectProgram, 0+cursorX, 0+5.0, 2.0, core.Browse.InputBoxHeight-10.0,
+			drawer.DrawRect(rectProgram, 0+cursorX, top+5.0, 2.0, core.Browse.InputBoxHeight-10.0,
 				[3]float32{0.0, 0.0, 0.0})
 			gl.UseProgram(textProgram)
 		}
@@ -38,3 +59,78 @@ func DrawURLBox(rectProgram uint32, textProgram uint32) {
 			utils.RGBToFloat32(150, 150, 150))
 	}
 }
+
+// DrawStatus shows the target of the hovered link in the bottom left corner.
+func DrawStatus(rectProgram uint32, textProgram uint32) {
+	if core.Browse.HoverLink == "" {
+		return
+	}
+
+	textWidth, _ := TextLIB.GetTextDimensions(core.Browse.HoverLink, 1.0)
+	height := TextLIB.GetLineHeight(1.0) + 6.0
+	y := float32(core.Browse.CurrentHeight) - height
+
+	drawer.DrawRect(rectProgram, 0, y, textWidth+12.0, height, utils.RGBToFloat32(200, 200, 200))
+
+	gl.UseProgram(textProgram)
+	TextLIB.DrawText(textProgram, core.Browse.HoverLink, 6.0, y+3.0+TextLIB.GetFontAscent(1.0), 1.0,
+		utils.RGBToFloat32(0, 0, 0))
+}
+
+// DrawTabStrip draws the tabs above the URL box, the active one in the URL
+// box colour.
+func DrawTabStrip(rectProgram uint32, textProgram uint32) {
+	height := core.Browse.TabBarHeight
+	drawer.DrawRect(rectProgram, 0, 0, float32(core.Browse.CurrentWidth), height, utils.RGBToFloat32(60, 60, 60))
+
+	const scale = 0.8
+	width := tabWidth()
+	textY := height/2 - TextLIB.GetLineHeight(scale)/2 + TextLIB.GetFontAscent(scale)
+
+	for i, tab := range core.Browse.Tabs {
+		x := width * float32(i)
+		color := utils.RGBToFloat32(120, 120, 120)
+		if i == core.Browse.ActiveTab {
+			color = utils.RGBToFloat32(200, 200, 200)
+		}
+		drawer.DrawRect(rectProgram, x, 4.0, width-1.0, height-4.0, color)
+
+		title := tab.Title()
+		if tab.Loading {
+			title = "Loading... " + title
+		}
+
+		textWidth := width - 16.0
+		if width > 48.0 {
+			textWidth -= 24.0
+			TextLIB.DrawText(textProgram, "x", x+width-18.0, textY, scale, utils.RGBToFloat32(60, 60, 60))
+		}
+		TextLIB.DrawText(textProgram, fitText(title, textWidth, scale), x+8.0, textY, scale, utils.RGBToFloat32(0, 0, 0))
+	}
+
+	plusX := width * float32(len(core.Browse.Tabs))
+	TextLIB.DrawText(textProgram, "+", plusX+newTabButtonWidth/2-5.0, textY, 1.0, utils.RGBToFloat32(200, 200, 200))
+}
+
+// fitText cuts text to width with an ellipsis.
+func fitText(text string, width, scale float32) string {
+	if textWidth, _ := TextLIB.GetTextDimensions(text, scale); textWidth <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if textWidth, _ := TextLIB.GetTextDimensions(string(runes[:mid])+"...", scale); textWidth <= width {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	if low == 0 {
+		return ""
+	}
+	return string(runes[:low]) + "..."
+}
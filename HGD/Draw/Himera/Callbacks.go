@@ -3,7 +3,7 @@ package himera
 import (
 	"unicode"
 
-	web "github.com/RDLxxx/Himera/HDS/core/web/html"
+	"github.com/RDLxxx/Himera/HGD/Draw/InputLIB"
 	"github.com/RDLxxx/Himera/HGD/Draw/TextLIB"
 	"github.com/RDLxxx/Himera/HGD/core"
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -11,9 +11,14 @@ import (
 )
 
 func CharCallback(window *glfw.Window, char rune) {
-	if core.Browse.InputBoxFocused && unicode.IsPrint(char) {
-		core.Browse.InputText = core.Browse.InputText[:core.Browse.CursorPosition] + string(char) + core.Browse.InputText[core.Browse.CursorPosition:]
-		core.Browse.CursorPosition++
+	if !unicode.IsPrint(char) {
+		return
+	}
+
+	if core.Browse.InputBoxFocused {
+		core.Browse.InputText, core.Browse.CursorPosition = InputLIB.Insert(core.Browse.InputText, core.Browse.CursorPosition, string(char))
+		MarkNeedsRedraw()
+	} else if core.Browse.HtmlRenderer != nil && core.Browse.HtmlRenderer.TypeText(string(char)) {
 		MarkNeedsRedraw()
 	}
 }
@@ -33,40 +38,73 @@ func ScrollCallback(window *glfw.Window, xoff, yoff float64) {
 			core.Browse.ScrollOffset += float32(yoff) * 25.0
 			UpdateScrollLimits()
 		}
+		updateHoverLink(window)
 		MarkNeedsRedraw()
 	}
 }
 
 func MouseButtonCallback(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
-	if action == glfw.Press && button == glfw.MouseButtonLeft {
-		xpos, ypos := window.GetCursorPos()
+	if action != glfw.Press {
+		return
+	}
+
+	xpos, ypos := window.GetCursorPos()
+	if tabStripClick(window, button, float32(xpos), float32(ypos)) {
+		return
+	}
 
-		inputBoxY := float32(5.0)
+	switch button {
+	case glfw.MouseButton4:
+		GoHistory(-1)
+	case glfw.MouseButton5:
+		GoHistory(1)
+	case glfw.MouseButtonMiddle:
+		if link := LinkAt(float32(xpos), float32(ypos)); link != "" {
+			OpenTab(link, false)
+		}
+	}
+
+	if button == glfw.MouseButtonLeft {
+
+		inputBoxY := core.Browse.TabBarHeight + 5.0
 		if float32(ypos) >= inputBoxY && float32(ypos) <= inputBoxY+core.Browse.InputBoxHeight &&
 			float32(xpos) >= 10.0 && float32(xpos) <= float32(core.Browse.CurrentWidth)-10.0 {
+			blurPage()
 			core.Browse.InputBoxFocused = true
-			textWidth, _ := TextLIB.GetTextDimensions(core.Browse.InputText, 1.0)
-			relativeX := float32(xpos) - 15.0
-			if relativeX < 0 {
-				core.Browse.CursorPosition = 0
-			} else if relativeX > textWidth {
-				core.Browse.CursorPosition = len(core.Browse.InputText)
-			} else {
-				core.Browse.CursorPosition = int(float32(len(core.Browse.InputText)) * (relativeX / textWidth))
-				if core.Browse.CursorPosition < 0 {
-					core.Browse.CursorPosition = 0
-				}
-				if core.Browse.CursorPosition > len(core.Browse.InputText) {
-					core.Browse.CursorPosition = len(core.Browse.InputText)
-				}
-			}
+			core.Browse.CursorPosition = InputLIB.CursorAt(core.Browse.InputText, float32(xpos), func(s string) float32 {
+				width, _ := TextLIB.GetTextDimensions(s, 1.0)
+				return width
+			})
 		} else {
 			core.Browse.InputBoxFocused = false
+			if clickControl(float32(xpos), float32(ypos)) {
+				MarkNeedsRedraw()
+				return
+			}
+			if link := LinkAt(float32(xpos), float32(ypos)); link != "" {
+				if mods&glfw.ModControl != 0 {
+					OpenTab(link, false)
+				} else {
+					FollowLink(link)
+				}
+			}
 		}
 		MarkNeedsRedraw()
 	}
 }
 
+func CursorPosCallback(window *glfw.Window, xpos, ypos float64) {
+	updateHoverLink(window)
+}
+
+func updateHoverLink(window *glfw.Window) {
+	xpos, ypos := window.GetCursorPos()
+	if link := LinkAt(float32(xpos), float32(ypos)); link != core.Browse.HoverLink {
+		core.Browse.HoverLink = link
+		MarkNeedsRedraw()
+	}
+}
+
 func WindowMaximizeCallback(window *glfw.Window, maximized bool) {
 	if !core.Browse.IsFullscreen {
 		core.Browse.IsMaximized = maximized
@@ -78,15 +116,7 @@ func FramebufferSizeCallback(window *glfw.Window, width, height int) {
 	core.Browse.CurrentHeight = height
 	gl.Viewport(0, 0, int32(width), int32(height))
 
-	if core.Browse.HtmlRenderer != nil {
-		ctx := &web.RenderContext{
-			Width:  float32(core.Browse.CurrentWidth),
-			Height: float32(core.Browse.CurrentHeight) - core.Browse.InputBoxHeight - 10.0,
-			Zoom:   core.Browse.Zoom,
-		}
-		core.Browse.ContentHeight = core.Browse.HtmlRenderer.CalculateContentHeight(ctx)
-		UpdateScrollLimits()
-	}
+	UpdateScrollLimits()
 
 	MarkNeedsRedraw()
 }
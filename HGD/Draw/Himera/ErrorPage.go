@@ -0,0 +1,133 @@
+package himera
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	h "github.com/RDLxxx/Himera/HDS/core/http"
+)
+
+// retryLink reloads the page it is on, see Navigate.
+const retryLink = "about:retry"
+
+// errorPage is shown in place of a page whose request got no response.
+func errorPage(link string, err error) string {
+	host := link
+	if u, parseErr := url.Parse(link); parseErr == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	host = html.EscapeString(host)
+
+	var title, message string
+	switch h.Classify(err) {
+	case h.FailureDNS:
+		title = "Server not found"
+		message = "Himera can't find the server at <b>" + host + "</b>. Check the address for typos, or your connection."
+	case h.FailureRefused:
+		title = "Unable to connect"
+		message = "<b>" + host + "</b> refused the connection. The server may be down or not accepting connections on this port."
+	case h.FailureTimeout:
+		title = "The connection timed out"
+		message = "<b>" + host + "</b> took too long to respond. The server may be overloaded, or your connection may be slow."
+	case h.FailureTLS:
+		title = "Secure connection failed"
+		message = "The certificate of <b>" + host + "</b> could not be verified, so the connection is not private. The page was not loaded."
+	case h.FailureRedirects:
+		title = "Too many redirects"
+		message = "<b>" + host + "</b> kept redirecting, more than " + strconv.Itoa(h.MaxRedirects) + " times. Clearing its cookies may help."
+	default:
+		title = "Failed to load page"
+		message = "Something went wrong while loading the page. Check your connection and try again."
+	}
+
+	return buildErrorPage(title, message, [][2]string{
+		{"URL", link},
+		{"Error", err.Error()},
+		{"Time", time.Now().Format(time.DateTime)},
+	})
+}
+
+// statusPage is shown for a response with a 4xx or 5xx status.
+func statusPage(link string, resp *h.Response) string {
+	var message string
+	switch resp.StatusCode {
+	case 401:
+		message = "The page needs you to sign in."
+	case 403:
+		message = "The server refused to show this page."
+	case 404:
+		message = "The page could not be found on the server. The link may be broken, or the page removed."
+	case 410:
+		message = "The page has been removed from the server."
+	case 429:
+		message = "The server got too many requests. Wait a moment before trying again."
+	case 502, 503, 504:
+		message = "The server or a gateway in front of it is unavailable. Try again later."
+	default:
+		if resp.StatusCode >= 500 {
+			message = "The server ran into a problem while handling the request."
+		} else {
+			message = "The server could not handle the request."
+		}
+	}
+
+	details := [][2]string{
+		{"URL", link},
+		{"Status", resp.Status},
+	}
+	if resp.URL != link {
+		details = append(details, [2]string{"Final URL", resp.URL})
+	}
+	if len(resp.Redirects) > 0 {
+		var hops []string
+		for _, r := range resp.Redirects {
+			hops = append(hops, strconv.Itoa(r.StatusCode)+" "+r.URL)
+		}
+		details = append(details, [2]string{"Redirects", strings.Join(hops, ", ")})
+	}
+	if server := resp.Header.Get("Server"); server != "" {
+		details = append(details, [2]string{"Server", server})
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		details = append(details, [2]string{"Content-Type", contentType})
+	}
+	details = append(details, [2]string{"Time", time.Now().Format(time.DateTime)})
+
+	return buildErrorPage(resp.Status, message, details)
+}
+
+func buildErrorPage(title, message string, details [][2]string) string {
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html><html><head><title>" + html.EscapeString(title) + "</title></head><body>")
+	page.WriteString("<h1>" + html.EscapeString(title) + "</h1>")
+	page.WriteString("<p>" + message + "</p>")
+	page.WriteString(`<p><a href="` + retryLink + `">Try again</a></p>`)
+
+	page.WriteString("<h3>Details</h3><table border>")
+	for _, detail := range details {
+		page.WriteString("<tr><th>" + html.EscapeString(detail[0]) + "</th><td>" + html.EscapeString(detail[1]) + "</td></tr>")
+	}
+	page.WriteString("</table></body></html>")
+
+	return page.String()
+}
+
+// failureStatus is the short form of a failure the URL box shows.
+func failureStatus(err error) string {
+	switch h.Classify(err) {
+	case h.FailureDNS:
+		return "DNS error"
+	case h.FailureRefused:
+		return "Connection refused"
+	case h.FailureTimeout:
+		return "Timed out"
+	case h.FailureTLS:
+		return "TLS error"
+	case h.FailureRedirects:
+		return "Redirect loop"
+	}
+	return "Error"
+}
@@ -0,0 +1,115 @@
+package himera
+
+import (
+	web "github.com/RDLxxx/Himera/HDS/core/web/html"
+	"github.com/RDLxxx/Himera/HGD/Draw/InputLIB"
+	"github.com/RDLxxx/Himera/HGD/core"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// editKey maps the keys the URL box and page fields share onto InputLIB.
+func editKey(key glfw.Key) (InputLIB.Key, bool) {
+	switch key {
+	case glfw.KeyBackspace:
+		return InputLIB.KeyBackspace, true
+	case glfw.KeyDelete:
+		return InputLIB.KeyDelete, true
+	case glfw.KeyLeft:
+		return InputLIB.KeyLeft, true
+	case glfw.KeyRight:
+		return InputLIB.KeyRight, true
+	case glfw.KeyHome:
+		return InputLIB.KeyHome, true
+	case glfw.KeyEnd:
+		return InputLIB.KeyEnd, true
+	case glfw.KeyUp:
+		return InputLIB.KeyUp, true
+	case glfw.KeyDown:
+		return InputLIB.KeyDown, true
+	}
+	return 0, false
+}
+
+func focusedControl() *web.Control {
+	if core.Browse.HtmlRenderer == nil {
+		return nil
+	}
+	return core.Browse.HtmlRenderer.FocusedControl()
+}
+
+// blurPage takes the focus away from the page's form controls.
+func blurPage() {
+	if core.Browse.HtmlRenderer != nil {
+		core.Browse.HtmlRenderer.Blur()
+	}
+}
+
+// controlKey handles a key for the focused form control, it reports false
+// for keys the control does not take.
+func controlKey(key glfw.Key, mods glfw.ModifierKey) bool {
+	renderer := core.Browse.HtmlRenderer
+	control := renderer.FocusedControl()
+
+	switch key {
+	case glfw.KeyEscape:
+		renderer.Blur()
+		return true
+	case glfw.KeyTab:
+		if mods&glfw.ModControl != 0 {
+			return false
+		}
+		focusNext(mods&glfw.ModShift != 0)
+		return true
+	case glfw.KeySpace:
+		return renderer.ActivateFocused()
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		switch control.Kind {
+		case web.TextAreaControl:
+			return renderer.TypeText("\n")
+		case web.TextControl, web.PasswordControl:
+			return renderer.SubmitFocused()
+		}
+		return renderer.ActivateFocused()
+	}
+
+	if mods&(glfw.ModAlt|glfw.ModControl) != 0 {
+		return false
+	}
+	if k, ok := editKey(key); ok {
+		renderer.EditFocused(k)
+		return true
+	}
+	return false
+}
+
+// focusNext moves the focus to the next form control on the page and
+// scrolls it into view.
+func focusNext(backward bool) {
+	renderer := core.Browse.HtmlRenderer
+	if renderer == nil || !renderer.FocusNext(backward) {
+		return
+	}
+	core.Browse.InputBoxFocused = false
+
+	rect, ok := renderer.FocusedRect()
+	if !ok {
+		return
+	}
+	ctx := pageContext()
+	if top := rect.Y + core.Browse.ScrollOffset; top < ctx.Y || top+rect.Height > ctx.Y+ctx.Height {
+		core.Browse.ScrollOffset = ctx.Y + ctx.Height/3 - rect.Y
+		UpdateScrollLimits()
+	}
+}
+
+// clickControl passes a click at the window point x, y to the page's form
+// controls, it reports whether one took it.
+func clickControl(x, y float32) bool {
+	if core.Browse.HtmlRenderer == nil || y < core.Browse.ChromeHeight() {
+		return false
+	}
+
+	ctx := pageContext()
+	ctx.ScrollOffset = core.Browse.ScrollOffset
+	return core.Browse.HtmlRenderer.ClickAt(ctx, x, y)
+}
@@ -0,0 +1,71 @@
+package himera
+
+import (
+	web "github.com/RDLxxx/Himera/HDS/core/web/html"
+	"github.com/RDLxxx/Himera/HGD/core"
+)
+
+// GoHistory moves delta entries through the history, the page comes from
+// the entry's cached response when there is one.
+func GoHistory(delta int) {
+	if !core.Browse.History.CanGo(delta) {
+		return
+	}
+
+	saveHistoryState()
+	entry := core.Browse.History.Go(delta)
+	setLink(entry.URL)
+	core.Browse.Zoom = entry.Zoom
+
+	index := core.Browse.History.Index
+	loadPage(entry.URL, "", entry.Form, entry.Page, false, func(err error) {
+		if core.Browse.History.Index != index {
+			return
+		}
+		entry := core.Browse.History.Current()
+		core.Browse.ScrollOffset = entry.ScrollOffset
+		entry.Title = core.Browse.HtmlRenderer.Title()
+		if err == nil {
+			entry.Page = core.Browse.HtmlRenderer.Source()
+		}
+	})
+	MarkNeedsRedraw()
+}
+
+// Reload fetches the current page again, keeping the scroll position.
+func Reload() {
+	scroll := core.Browse.ScrollOffset
+	index := core.Browse.History.Index
+
+	var form *web.FormSubmission
+	if entry := core.Browse.History.Current(); entry != nil {
+		form = entry.Form
+	}
+
+	loadPage(core.Browse.Link, "", form, "", true, func(err error) {
+		core.Browse.ScrollOffset = scroll
+		if entry := core.Browse.History.Current(); entry != nil && core.Browse.History.Index == index {
+			entry.Title = core.Browse.HtmlRenderer.Title()
+			entry.Page = ""
+			if err == nil {
+				entry.Page = core.Browse.HtmlRenderer.Source()
+			}
+		}
+	})
+	MarkNeedsRedraw()
+}
+
+// saveHistoryState records where the current page was left so going back
+// to it restores the view.
+func saveHistoryState() {
+	entry := core.Browse.History.Current()
+	if entry == nil {
+		return
+	}
+
+	entry.ScrollOffset = core.Browse.ScrollOffset
+	entry.Zoom = core.Browse.Zoom
+	if core.Browse.HtmlRenderer != nil {
+		entry.Title = core.Browse.HtmlRenderer.Title()
+	}
+}
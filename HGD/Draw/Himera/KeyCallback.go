@@ -1,7 +1,7 @@
 package himera
 
 import (
-	web "github.com/RDLxxx/Himera/HDS/core/web/html"
+	"github.com/RDLxxx/Himera/HGD/Draw/InputLIB"
 	"github.com/RDLxxx/Himera/HGD/core"
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
@@ -10,60 +10,85 @@ func KeyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Ac
 	if action == glfw.Press || action == glfw.Repeat {
 		needsRedraw := false
 
-		if core.Browse.InputBoxFocused {
+		// keys that leave the URL box must not also act on the page
+		inputFocused := core.Browse.InputBoxFocused
+		if inputFocused {
 			switch key {
 			case glfw.KeyEnter:
-				core.Browse.Link = core.Browse.InputText
-				UpdateContent(core.Browse.Link, core.Browse.Ua)
 				core.Browse.InputBoxFocused = false
+				Navigate(core.Browse.InputText)
 				needsRedraw = true
 			case glfw.KeyEscape:
 				core.Browse.InputBoxFocused = false
 				needsRedraw = true
-			case glfw.KeyBackspace:
-				if core.Browse.CursorPosition > 0 {
-					core.Browse.InputText = core.Browse.InputText[:core.Browse.CursorPosition-1] + core.Browse.InputText[core.Browse.CursorPosition:]
-					core.Browse.CursorPosition--
-					needsRedraw = true
-				}
-			case glfw.KeyDelete:
-				if core.Browse.CursorPosition < len(core.Browse.InputText) {
-					core.Browse.InputText = core.Browse.InputText[:core.Browse.CursorPosition] + core.Browse.InputText[core.Browse.CursorPosition+1:]
-					needsRedraw = true
-				}
-			case glfw.KeyLeft:
-				if core.Browse.CursorPosition > 0 {
-					core.Browse.CursorPosition--
-					needsRedraw = true
-				}
-			case glfw.KeyRight:
-				if core.Browse.CursorPosition < len(core.Browse.InputText) {
-					core.Browse.CursorPosition++
-					needsRedraw = true
-				}
-			case glfw.KeyHome:
-				core.Browse.CursorPosition = 0
-				needsRedraw = true
-			case glfw.KeyEnd:
-				core.Browse.CursorPosition = len(core.Browse.InputText)
-				needsRedraw = true
 			case glfw.KeyA:
 				if mods&glfw.ModControl != 0 {
 					core.Browse.CursorPosition = len(core.Browse.InputText)
 					needsRedraw = true
 				}
+			default:
+				if k, ok := editKey(key); ok && mods&glfw.ModAlt == 0 {
+					core.Browse.InputText, core.Browse.CursorPosition, _ = InputLIB.Edit(core.Browse.InputText, core.Browse.CursorPosition, k, false)
+					needsRedraw = true
+				}
 			}
 		}
 
+		controlFocused := !inputFocused && focusedControl() != nil
+		if controlFocused && controlKey(key, mods) {
+			MarkNeedsRedraw()
+			return
+		}
+
 		switch key {
 		case glfw.KeyF5:
-			UpdateContent(core.Browse.Link, core.Browse.Ua)
+			Reload()
 			needsRedraw = true
+		case glfw.KeyLeft:
+			if mods&glfw.ModAlt != 0 {
+				GoHistory(-1)
+				needsRedraw = true
+			}
+		case glfw.KeyRight:
+			if mods&glfw.ModAlt != 0 {
+				GoHistory(1)
+				needsRedraw = true
+			}
 		case glfw.KeyF11:
 			ToggleFullscreen(window)
 			needsRedraw = true
+		case glfw.KeyT:
+			if mods&glfw.ModControl != 0 {
+				OpenTab("", true)
+				needsRedraw = true
+			}
+		case glfw.KeyW:
+			if mods&glfw.ModControl != 0 {
+				CloseTab(window, core.Browse.ActiveTab)
+				needsRedraw = true
+			}
+		case glfw.KeyTab:
+			if mods&glfw.ModControl != 0 {
+				step := 1
+				if mods&glfw.ModShift != 0 {
+					step = len(core.Browse.Tabs) - 1
+				}
+				SelectTab((core.Browse.ActiveTab + step) % len(core.Browse.Tabs))
+				needsRedraw = true
+			}
+		case glfw.Key1, glfw.Key2, glfw.Key3, glfw.Key4, glfw.Key5, glfw.Key6, glfw.Key7, glfw.Key8:
+			if mods&glfw.ModControl != 0 {
+				SelectTab(int(key - glfw.Key1))
+				needsRedraw = true
+			}
+		case glfw.Key9:
+			if mods&glfw.ModControl != 0 {
+				SelectTab(len(core.Browse.Tabs) - 1)
+				needsRedraw = true
+			}
 		case glfw.KeyL:
 			if mods&glfw.ModControl != 0 {
+				blurPage()
 				core.Browse.InputBoxFocused = true
 				core.Browse.CursorPosition = len(core.Browse.InputText)
 				needsRedraw = true
@@ -82,26 +107,33 @@ func KeyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Ac
 			if mods&glfw.ModControl != 0 {
 				core.Browse.Zoom = 1.0
 				core.Browse.ScrollOffset = 0
-				if core.Browse.HtmlRenderer != nil {
-					ctx := &web.RenderContext{
-						Width:  float32(core.Browse.CurrentWidth),
-						Height: float32(core.Browse.CurrentHeight) - core.Browse.InputBoxHeight - 10.0,
-						Zoom:   core.Browse.Zoom,
-					}
-					core.Browse.ContentHeight = core.Browse.HtmlRenderer.CalculateContentHeight(ctx)
-				}
+				UpdateScrollLimits()
 				needsRedraw = true
 			}
 		}
 
-		if !core.Browse.InputBoxFocused {
+		if !inputFocused && !controlFocused {
 			switch key {
+			case glfw.KeyTab:
+				if mods&glfw.ModControl == 0 {
+					focusNext(mods&glfw.ModShift != 0)
+					needsRedraw = true
+				}
+			case glfw.KeyEscape:
+				StopLoading()
+			case glfw.KeyBackspace:
+				if mods&glfw.ModShift != 0 {
+					GoHistory(1)
+				} else {
+					GoHistory(-1)
+				}
+				needsRedraw = true
 			case glfw.KeyHome:
 				core.Browse.ScrollOffset = 0
 				needsRedraw = true
 			case glfw.KeyEnd:
 				UpdateScrollLimits()
-				availableHeight := float32(core.Browse.CurrentHeight) - core.Browse.InputBoxHeight - 20.0
+				availableHeight := float32(core.Browse.CurrentHeight) - core.Browse.ChromeHeight() - 20.0
 				core.Browse.ScrollOffset = -(core.Browse.ContentHeight - availableHeight*0.9)
 				if core.Browse.ScrollOffset > 0 {
 					core.Browse.ScrollOffset = 0
@@ -0,0 +1,191 @@
+package himera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+
+	h "github.com/RDLxxx/Himera/HDS/core/http"
+	web "github.com/RDLxxx/Himera/HDS/core/web/html"
+	"github.com/RDLxxx/Himera/HGD/browser"
+	"github.com/RDLxxx/Himera/HGD/core"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// pageLoad is a page fetched and parsed off the main thread, handed back
+// to the render loop through loads.
+type pageLoad struct {
+	tab      *browser.Tab
+	id       int
+	renderer *web.HTMLRenderer
+	status   string
+	err      error
+	done     func(err error)
+}
+
+var loads = make(chan pageLoad, 16)
+
+// loadPage replaces the page of the active tab with link in the
+// background, page is used instead of fetching when it is not empty and
+// reload revalidates cached responses. initiator is the page that started
+// the navigation, empty for the user. form is posted to link when it is
+// not nil. done runs on the main thread with the tab active once the page
+// is shown.
+func loadPage(link, initiator string, form *web.FormSubmission, page string, reload bool, done func(err error)) {
+	tab := core.Browse.Tab
+	tab.StopLoading()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if reload {
+		ctx = h.Revalidate(ctx)
+	}
+	// the browser's own pages navigate on behalf of the user
+	if !strings.HasPrefix(initiator, "about:") {
+		ctx = h.WithNavigation(ctx, initiator)
+	}
+	tab.Loading = true
+	tab.CancelLoad = cancel
+	id := tab.LoadID
+	ua := core.Browse.Ua
+
+	go func() {
+		renderer, status, err := fetchPage(ctx, link, form, page, ua)
+		if ctx.Err() != nil {
+			return
+		}
+
+		loads <- pageLoad{tab: tab, id: id, renderer: renderer, status: status, err: err, done: done}
+		glfw.PostEmptyEvent()
+	}()
+}
+
+// fetchPage loads link into a renderer. A request that fails, or gets a
+// 4xx or 5xx status, shows an error page and returns the error along with
+// the status the URL box shows for it.
+func fetchPage(ctx context.Context, link string, form *web.FormSubmission, page string, ua string) (*web.HTMLRenderer, string, error) {
+	var err error
+	var status string
+	pageURL := link
+	if internal, ok := aboutPage(link); ok && page == "" {
+		page = internal
+	}
+	if page == "" {
+		req := h.NewRequest("GET", link)
+		if form != nil && form.Method == "POST" {
+			req = h.NewRequest("POST", link).WithBody(form.ContentType, bytes.NewReader(form.Body))
+		}
+
+		var resp *h.Response
+		if resp, err = req.WithContext(ctx).WithUserAgent(ua).Do(); err == nil {
+			err = resp.ReadPage()
+		}
+
+		switch {
+		case err != nil:
+			page, status = errorPage(link, err), failureStatus(err)
+		case resp.StatusCode >= 400:
+			page, status = statusPage(link, resp), resp.Status
+			err = errors.New(resp.Status)
+		default:
+			page = documentFor(resp)
+		}
+
+		// relative links resolve against where the redirects ended up
+		if resp != nil {
+			pageURL = resp.URL
+			if _, fragment := web.SplitFragment(link); fragment != "" && !strings.Contains(pageURL, "#") {
+				pageURL += "#" + fragment
+			}
+		}
+	}
+
+	renderer := web.NewHTMLRenderer(page, pageURL, ua)
+	renderer.Parse(ctx)
+	return renderer, status, err
+}
+
+// documentFor is the page shown for a response, text and images that are
+// not HTML get wrapped in one.
+func documentFor(resp *h.Response) string {
+	contentType := resp.ContentType()
+	name := path.Base(resp.URL)
+	if u, err := url.Parse(resp.URL); err == nil && u.Path != "" {
+		name = path.Base(u.Path)
+	}
+	head := "<!DOCTYPE html><html><head><title>" + html.EscapeString(name) + "</title></head><body>"
+
+	switch {
+	case contentType == "", contentType == "text/html", contentType == "application/xhtml+xml":
+		return resp.Page
+	case strings.HasPrefix(contentType, "image/"):
+		return head + `<img src="` + html.EscapeString(resp.URL) + `" alt="` + html.EscapeString(name) + `"></body></html>`
+	case strings.HasPrefix(contentType, "text/"), contentType == "application/json",
+		contentType == "application/javascript", strings.HasSuffix(contentType, "+xml"), strings.HasSuffix(contentType, "/xml"):
+		return head + "<pre>" + html.EscapeString(resp.Page) + "</pre></body></html>"
+	}
+	return head + "<h1>" + html.EscapeString(name) + "</h1><p>Himera can't display files of type " + html.EscapeString(contentType) + ".</p></body></html>"
+}
+
+// ProcessLoads shows the pages that finished loading, it is called from
+// the render loop.
+func ProcessLoads() {
+	for {
+		select {
+		case load := <-loads:
+			finishLoad(load)
+		default:
+			return
+		}
+	}
+}
+
+func finishLoad(load pageLoad) {
+	tab := load.tab
+	if tab.LoadID != load.id || !tab.Loading {
+		load.renderer.Release()
+		return
+	}
+
+	tab.CancelLoad()
+	tab.CancelLoad = nil
+	tab.Loading = false
+
+	if tab.HtmlRenderer != nil {
+		tab.HtmlRenderer.Release()
+	}
+	tab.HtmlRenderer = load.renderer
+	tab.Status = load.status
+	tab.HtmlRenderer.ResourceLoaded = glfw.PostEmptyEvent
+	renderer := load.renderer
+	tab.HtmlRenderer.FormSubmitted = func(form *web.FormSubmission) {
+		withTab(tab, func() { Submit(form, renderer.URL()) })
+	}
+
+	withTab(tab, func() {
+		if final := tab.HtmlRenderer.URL(); final != core.Browse.Link {
+			if entry := core.Browse.History.Current(); entry != nil && entry.URL == core.Browse.Link {
+				entry.URL = final
+			}
+			if !core.Browse.InputBoxFocused {
+				setLink(final)
+			} else {
+				core.Browse.Link = final
+			}
+		}
+		load.done(load.err)
+		UpdateScrollLimits()
+	})
+	MarkNeedsRedraw()
+}
+
+// StopLoading cancels the load of the active tab.
+func StopLoading() {
+	if core.Browse.Loading {
+		core.Browse.StopLoading()
+		MarkNeedsRedraw()
+	}
+}
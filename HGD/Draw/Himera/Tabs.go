@@ -0,0 +1,109 @@
+package himera
+
+import (
+	"github.com/RDLxxx/Himera/HGD/browser"
+	"github.com/RDLxxx/Himera/HGD/core"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const newTabButtonWidth = 32.0
+
+// OpenTab opens link, one of the page shown, in a new tab, a blank tab
+// focuses the URL box. Background tabs are loaded without leaving the
+// current one.
+func OpenTab(link string, activate bool) {
+	previous := core.Browse.ActiveTab
+	initiator := pageLink()
+	core.Browse.SelectTab(core.Browse.OpenTab())
+
+	if link != "" {
+		navigate(link, initiator)
+	}
+
+	if activate {
+		core.Browse.InputBoxFocused = link == ""
+	} else {
+		core.Browse.SelectTab(previous)
+	}
+	tabChanged()
+}
+
+func SelectTab(index int) {
+	if index == core.Browse.ActiveTab {
+		return
+	}
+	core.Browse.SelectTab(index)
+	core.Browse.InputBoxFocused = false
+	tabChanged()
+}
+
+// CloseTab closes the tab at index, closing the last one closes the window.
+func CloseTab(window *glfw.Window, index int) {
+	if !core.Browse.CloseTab(index) {
+		window.SetShouldClose(true)
+		return
+	}
+	tabChanged()
+}
+
+// withTab runs fn with tab standing in for the active one, so the view
+// helpers work on a tab in the background.
+func withTab(tab *browser.Tab, fn func()) {
+	core.Browse.Tab = tab
+	defer func() { core.Browse.Tab = core.Browse.Tabs[core.Browse.ActiveTab] }()
+
+	fn()
+}
+
+func tabChanged() {
+	core.Browse.HoverLink = ""
+	UpdateScrollLimits()
+	MarkNeedsRedraw()
+}
+
+// tabWidth is the width of every tab in the strip, they shrink to fit the
+// window.
+func tabWidth() float32 {
+	available := float32(core.Browse.CurrentWidth) - newTabButtonWidth
+	return min(220.0, available/float32(len(core.Browse.Tabs)))
+}
+
+// tabAt finds what of the tab strip is under x, y. The index is
+// len(core.Browse.Tabs) for the new tab button, closeButton reports a hit
+// on the tab's close button.
+func tabAt(x, y float32) (index int, closeButton bool, ok bool) {
+	if y < 0 || y >= core.Browse.TabBarHeight || x < 0 {
+		return 0, false, false
+	}
+
+	width := tabWidth()
+	index = int(x / width)
+	if index >= len(core.Browse.Tabs) {
+		if x < width*float32(len(core.Browse.Tabs))+newTabButtonWidth {
+			return len(core.Browse.Tabs), false, true
+		}
+		return 0, false, false
+	}
+
+	right := width * float32(index+1)
+	return index, x >= right-24.0 && width > 48.0, true
+}
+
+func tabStripClick(window *glfw.Window, button glfw.MouseButton, x, y float32) bool {
+	index, closeButton, ok := tabAt(x, y)
+	if !ok {
+		return y < core.Browse.TabBarHeight
+	}
+
+	switch {
+	case index == len(core.Browse.Tabs):
+		if button == glfw.MouseButtonLeft {
+			OpenTab("", true)
+		}
+	case button == glfw.MouseButtonMiddle || (button == glfw.MouseButtonLeft && closeButton):
+		CloseTab(window, index)
+	case button == glfw.MouseButtonLeft:
+		SelectTab(index)
+	}
+	return true
+}
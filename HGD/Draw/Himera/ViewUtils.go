@@ -1,7 +1,7 @@
 package himera
 
 import (
-	web "github.com/RDLxxx/Himera/HDS/core/web/html"
+	drawer "github.com/RDLxxx/Himera/HGD/Draw/Drawer"
 	"github.com/RDLxxx/Himera/HGD/core"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -13,14 +13,8 @@ func UpdateScrollLimits() {
 		return
 	}
 
-	availableHeight := float32(core.Browse.CurrentHeight) - core.Browse.InputBoxHeight - 20.0
-	ctx := &web.RenderContext{
-		Width:  float32(core.Browse.CurrentWidth) - 20.0*core.Browse.Zoom,
-		Height: availableHeight,
-		X:      10.0 * core.Browse.Zoom,
-		Y:      core.Browse.InputBoxHeight + 15.0*core.Browse.Zoom,
-		Zoom:   core.Browse.Zoom,
-	}
+	ctx := pageContext()
+	availableHeight := ctx.Height
 	core.Browse.ContentHeight = core.Browse.HtmlRenderer.CalculateContentHeight(ctx)
 
 	maxScrollOffset := float32(0.0)
@@ -50,14 +44,7 @@ func AdjustZoom(delta float32) {
 		core.Browse.Zoom = newZoom
 		core.Browse.ScrollOffset = 0
 
-		if core.Browse.HtmlRenderer != nil {
-			ctx := &web.RenderContext{
-				Width:  float32(core.Browse.CurrentWidth),
-				Height: float32(core.Browse.CurrentHeight) - core.Browse.InputBoxHeight - 20.0,
-				Zoom:   core.Browse.Zoom,
-			}
-			core.Browse.ContentHeight = core.Browse.HtmlRenderer.CalculateContentHeight(ctx)
-		}
+		UpdateScrollLimits()
 
 		MarkNeedsRedraw()
 	}
@@ -93,20 +80,20 @@ func ToggleFullscreen(window *glfw.Window) {
 
 	gl.Viewport(0, 0, int32(core.Browse.CurrentWidth), int32(core.Browse.CurrentHeight))
 
-	if core.Browse.HtmlRenderer != nil {
-		ctx := &web.RenderContext{
-			Width:  float32(core.Browse.CurrentWidth),
-			Height: float32(core.Browse.CurrentHeight) - core.Browse.InputBoxHeight - 20.0,
-			Zoom:   core.Browse.Zoom,
-		}
-		core.Browse.ContentHeight = core.Browse.HtmlRenderer.CalculateContentHeight(ctx)
-		UpdateScrollLimits()
-	}
+	UpdateScrollLimits()
 
 	MarkNeedsRedraw()
 }
 
 func CheckNeedsRedraw() bool {
+	if core.Browse.HtmlRenderer != nil {
+		if resources := core.Browse.HtmlRenderer.ResourceGeneration(); resources != core.Browse.RState.LastResources {
+			core.Browse.RState.LastResources = resources
+			UpdateScrollLimits()
+			core.Browse.RState.NeedsRedraw = true
+		}
+	}
+
 	if core.Browse.RState.NeedsRedraw ||
 		core.Browse.RState.LastWidth != core.Browse.CurrentWidth ||
 		core.Browse.RState.LastHeight != core.Browse.CurrentHeight ||
@@ -128,7 +115,7 @@ func CheckNeedsRedraw() bool {
 		return true
 	}
 
-	if core.Browse.InputBoxFocused {
+	if core.Browse.InputBoxFocused || core.Browse.Loading {
 		return true
 	}
 
@@ -143,6 +130,8 @@ func UpdateProjection(program uint32) {
 		-1, 1, 0, 1,
 	}
 
+	drawer.SetViewport(core.Browse.CurrentWidth, core.Browse.CurrentHeight)
+
 	gl.UseProgram(program)
 	projLoc := gl.GetUniformLocation(program, gl.Str("projection\x00"))
 	gl.UniformMatrix4fv(projLoc, 1, false, &projection[0])
@@ -0,0 +1,137 @@
+package InputLIB
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Key is an editing key, callers map their window system keys onto it.
+type Key int
+
+const (
+	KeyBackspace Key = iota
+	KeyDelete
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyUp
+	KeyDown
+)
+
+// Insert puts s into text at the cursor, a byte offset on a rune boundary,
+// and moves the cursor past it.
+func Insert(text string, cursor int, s string) (string, int) {
+	cursor = clampCursor(text, cursor)
+	return text[:cursor] + s + text[cursor:], cursor + len(s)
+}
+
+// Edit applies key to text. Home, End, Up and Down work on lines when
+// multiline is set, on the whole text otherwise. It reports whether the
+// text or the cursor changed.
+func Edit(text string, cursor int, key Key, multiline bool) (string, int, bool) {
+	cursor = clampCursor(text, cursor)
+	start, end := 0, len(text)
+	if multiline {
+		start, end = lineBounds(text, cursor)
+	}
+
+	switch key {
+	case KeyBackspace:
+		if cursor == 0 {
+			return text, cursor, false
+		}
+		_, size := utf8.DecodeLastRuneInString(text[:cursor])
+		return text[:cursor-size] + text[cursor:], cursor - size, true
+
+	case KeyDelete:
+		if cursor == len(text) {
+			return text, cursor, false
+		}
+		_, size := utf8.DecodeRuneInString(text[cursor:])
+		return text[:cursor] + text[cursor+size:], cursor, true
+
+	case KeyLeft:
+		if cursor == 0 {
+			return text, cursor, false
+		}
+		_, size := utf8.DecodeLastRuneInString(text[:cursor])
+		return text, cursor - size, true
+
+	case KeyRight:
+		if cursor == len(text) {
+			return text, cursor, false
+		}
+		_, size := utf8.DecodeRuneInString(text[cursor:])
+		return text, cursor + size, true
+
+	case KeyHome:
+		return text, start, cursor != start
+
+	case KeyEnd:
+		return text, end, cursor != end
+
+	case KeyUp, KeyDown:
+		if !multiline {
+			return text, cursor, false
+		}
+		column := utf8.RuneCountInString(text[start:cursor])
+		if key == KeyUp {
+			if start == 0 {
+				return text, cursor, false
+			}
+			start, end = lineBounds(text, start-1)
+		} else {
+			if end == len(text) {
+				return text, cursor, false
+			}
+			start, end = lineBounds(text, end+1)
+		}
+		return text, start + runeOffset(text[start:end], column), true
+	}
+
+	return text, cursor, false
+}
+
+// CursorAt is the cursor position closest to x in a line of text, measure
+// gives the width of a prefix of it.
+func CursorAt(text string, x float32, measure func(string) float32) int {
+	previous := float32(0)
+	for i, r := range text {
+		next := measure(text[:i+utf8.RuneLen(r)])
+		if x < (previous+next)/2 {
+			return i
+		}
+		previous = next
+	}
+	return len(text)
+}
+
+// lineBounds returns the byte range of the line the cursor is on, without
+// its newline.
+func lineBounds(text string, cursor int) (int, int) {
+	start := strings.LastIndexByte(text[:cursor], '\n') + 1
+	end := strings.IndexByte(text[cursor:], '\n')
+	if end < 0 {
+		return start, len(text)
+	}
+	return start, cursor + end
+}
+
+func runeOffset(line string, column int) int {
+	for i := range line {
+		if column == 0 {
+			return i
+		}
+		column--
+	}
+	return len(line)
+}
+
+func clampCursor(text string, cursor int) int {
+	cursor = max(0, min(cursor, len(text)))
+	for cursor > 0 && cursor < len(text) && !utf8.RuneStart(text[cursor]) {
+		cursor--
+	}
+	return cursor
+}
@@ -16,11 +16,15 @@ type CompiledShaders struct {
 	TextShaderFrag   uint32
 	RectShaderVertex uint32
 	RectShaderFrag   uint32
+
+	ImageShaderVertex uint32
+	ImageShaderFrag   uint32
 }
 
 type ShadersPrograms struct {
-	TextShaderProgram uint32
-	RectShaderProgram uint32
+	TextShaderProgram  uint32
+	RectShaderProgram  uint32
+	ImageShaderProgram uint32
 }
 
 func CompileShader(source string, shaderType uint32) (uint32, error) {
@@ -60,11 +64,21 @@ func CompileShaders() (CompiledShaders, error) {
 	if err != nil {
 		return CompiledShaders{}, fmt.Errorf("frag ? %v", err)
 	}
+	isv, err := CompileShader(shaders.ImageShaders.Vertex, gl.VERTEX_SHADER)
+	if err != nil {
+		return CompiledShaders{}, fmt.Errorf("vertex ? %v", err)
+	}
+	isf, err := CompileShader(shaders.ImageShaders.Frag, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return CompiledShaders{}, fmt.Errorf("frag ? %v", err)
+	}
 	return CompiledShaders{
-		TextShaderVertex: tvs,
-		TextShaderFrag:   tfs,
-		RectShaderVertex: rsv,
-		RectShaderFrag:   rsf,
+		TextShaderVertex:  tvs,
+		TextShaderFrag:    tfs,
+		RectShaderVertex:  rsv,
+		RectShaderFrag:    rsf,
+		ImageShaderVertex: isv,
+		ImageShaderFrag:   isf,
 	}, nil
 
 }
@@ -105,6 +119,21 @@ func MakeShadersPrgs() (ShadersPrograms, error) {
 		return ShadersPrograms{}, fmt.Errorf("link program ? %v", log)
 	}
 
+	// Image
+	Ip := gl.CreateProgram()
+	gl.AttachShader(Ip, Shaders.ImageShaderVertex)
+	gl.AttachShader(Ip, Shaders.ImageShaderFrag)
+	gl.LinkProgram(Ip)
+	var statusImage int32
+	gl.GetProgramiv(Ip, gl.LINK_STATUS, &statusImage)
+	if statusImage == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(Ip, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(Ip, logLength, nil, gl.Str(log))
+		return ShadersPrograms{}, fmt.Errorf("link program ? %v", log)
+	}
+
 	// Optimization ♥
 	// Text
 	gl.DeleteShader(Shaders.TextShaderVertex)
@@ -116,10 +145,16 @@ func MakeShadersPrgs() (ShadersPrograms, error) {
 	gl.DeleteShader(Shaders.RectShaderFrag)
 	gl.DetachShader(Rp, Shaders.RectShaderVertex)
 	gl.DetachShader(Rp, Shaders.RectShaderFrag)
+	// Image
+	gl.DeleteShader(Shaders.ImageShaderVertex)
+	gl.DeleteShader(Shaders.ImageShaderFrag)
+	gl.DetachShader(Ip, Shaders.ImageShaderVertex)
+	gl.DetachShader(Ip, Shaders.ImageShaderFrag)
 
 	return ShadersPrograms{
-		TextShaderProgram: Tp,
-		RectShaderProgram: Rp,
+		TextShaderProgram:  Tp,
+		RectShaderProgram:  Rp,
+		ImageShaderProgram: Ip,
 	}, nil
 }
 
@@ -5,17 +5,7 @@ func GetTextDimensions(text string, scale float32) (width, height float32) {
 		return 0, 0
 	}
 
-	width = 0
-	height = float32(FontMetrics.Height>>6) * scale
-
-	for _, ch := range text {
-		char := Characters[ch]
-		if char != nil {
-			width += float32(char.Advance) * scale
-		}
-	}
-
-	return width, height
+	return MeasureText(text, scale), float32(FontMetrics.Height>>6) * scale
 }
 
 func GetLineHeight(scale float32) float32 {
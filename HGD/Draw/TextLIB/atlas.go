@@ -0,0 +1,95 @@
+package TextLIB
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	atlasSize    = 1024
+	atlasPadding = 1
+)
+
+// atlasPage is one single-channel texture glyphs are packed into, row by row.
+type atlasPage struct {
+	texture   uint32
+	x, y      int
+	rowHeight int
+}
+
+var atlasPages []*atlasPage
+
+func newAtlasPage() *atlasPage {
+	page := &atlasPage{}
+
+	gl.GenTextures(1, &page.texture)
+	gl.BindTexture(gl.TEXTURE_2D, page.texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, atlasSize, atlasSize, 0, gl.RED, gl.UNSIGNED_BYTE, nil)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+
+	atlasPages = append(atlasPages, page)
+	return page
+}
+
+func (p *atlasPage) reserve(width, height int) (int, int, bool) {
+	if p.x+width+atlasPadding > atlasSize {
+		p.x = 0
+		p.y += p.rowHeight + atlasPadding
+		p.rowHeight = 0
+	}
+	if p.y+height+atlasPadding > atlasSize {
+		return 0, 0, false
+	}
+
+	x, y := p.x, p.y
+	p.x += width + atlasPadding
+	p.rowHeight = max(p.rowHeight, height)
+
+	return x, y, true
+}
+
+// packGlyph uploads a glyph coverage mask into the atlas and returns the page
+// index and the texture coordinates of the glyph (u0, v0, u1, v1).
+func packGlyph(img *image.Alpha) (int, [4]float32, error) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if width+atlasPadding > atlasSize || height+atlasPadding > atlasSize {
+		return 0, [4]float32{}, fmt.Errorf("glyph too large %dx%d", width, height)
+	}
+
+	index := len(atlasPages) - 1
+	var x, y int
+	ok := false
+	if index >= 0 {
+		x, y, ok = atlasPages[index].reserve(width, height)
+	}
+	if !ok {
+		newAtlasPage()
+		index = len(atlasPages) - 1
+		x, y, _ = atlasPages[index].reserve(width, height)
+	}
+
+	gl.BindTexture(gl.TEXTURE_2D, atlasPages[index].texture)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(x), int32(y), int32(width), int32(height), gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	uv := [4]float32{
+		float32(x) / atlasSize,
+		float32(y) / atlasSize,
+		float32(x+width) / atlasSize,
+		float32(y+height) / atlasSize,
+	}
+
+	return index, uv, nil
+}
+
+func resetAtlas() {
+	for _, page := range atlasPages {
+		gl.DeleteTextures(1, &page.texture)
+	}
+	atlasPages = nil
+}
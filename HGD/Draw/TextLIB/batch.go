@@ -0,0 +1,107 @@
+package TextLIB
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// floats per vertex: position, texture coordinates and colour.
+const vertexStride = 7
+
+// textBatch collects the glyph quads of a frame per atlas page, so a whole
+// page of text is drawn with one call per page instead of one per glyph.
+type textBatch struct {
+	vao, vbo    uint32
+	initialized bool
+
+	program  uint32
+	vertices [][]float32
+	pending  bool
+}
+
+var batch = &textBatch{}
+
+func (b *textBatch) init() {
+	gl.GenVertexArrays(1, &b.vao)
+	gl.GenBuffers(1, &b.vbo)
+
+	gl.BindVertexArray(b.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 4, gl.FLOAT, false, vertexStride*4, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointerWithOffset(1, 3, gl.FLOAT, false, vertexStride*4, 4*4)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+
+	b.initialized = true
+}
+
+func (b *textBatch) add(program uint32, page int, x0, y0, x1, y1 float32, uv [4]float32, color [3]float32) {
+	if b.pending && b.program != program {
+		Flush()
+	}
+	b.program = program
+	b.pending = true
+
+	for len(b.vertices) <= page {
+		b.vertices = append(b.vertices, nil)
+	}
+
+	r, g, bl := color[0], color[1], color[2]
+	b.vertices[page] = append(b.vertices[page],
+		x0, y1, uv[0], uv[3], r, g, bl,
+		x0, y0, uv[0], uv[1], r, g, bl,
+		x1, y0, uv[2], uv[1], r, g, bl,
+
+		x0, y1, uv[0], uv[3], r, g, bl,
+		x1, y0, uv[2], uv[1], r, g, bl,
+		x1, y1, uv[2], uv[3], r, g, bl,
+	)
+}
+
+// Flush draws all text queued by DrawText since the last flush. Anything drawn
+// with other programs on top of text must flush first to keep the order.
+func Flush() {
+	b := batch
+	if !b.pending {
+		return
+	}
+	b.pending = false
+
+	if !b.initialized {
+		b.init()
+	}
+
+	gl.UseProgram(b.program)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	gl.BindVertexArray(b.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+
+	for page, vertices := range b.vertices {
+		if len(vertices) == 0 || page >= len(atlasPages) {
+			continue
+		}
+
+		gl.BindTexture(gl.TEXTURE_2D, atlasPages[page].texture)
+		gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STREAM_DRAW)
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(vertices)/vertexStride))
+
+		b.vertices[page] = vertices[:0]
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+func CleanupGLResources() {
+	if batch.initialized {
+		gl.DeleteVertexArrays(1, &batch.vao)
+		gl.DeleteBuffers(1, &batch.vbo)
+		batch.initialized = false
+	}
+	resetAtlas()
+}
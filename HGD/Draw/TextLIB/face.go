@@ -0,0 +1,265 @@
+package TextLIB
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	FamilyDefault   = ""
+	FamilyMonospace = "monospace"
+)
+
+// FaceKey selects a face by family, weight and style.
+type FaceKey struct {
+	Family string
+	Bold   bool
+	Italic bool
+}
+
+// Face is a family in one weight and style with its own glyph set. Runes
+// the face's fonts lack come from the rest of the chain, made bold or
+// oblique synthetically when the face asks for it.
+type Face struct {
+	Key     FaceKey
+	Metrics font.Metrics
+
+	chain   []faceFont
+	glyphs  map[rune]*Character
+	missing map[rune]bool
+}
+
+type faceFont struct {
+	src          *fontSource
+	synthBold    bool
+	synthOblique bool
+}
+
+// shear of synthetic oblique glyphs, about 11 degrees
+const obliqueSlant = 0.2
+
+var faces = make(map[FaceKey]*Face)
+
+// GetFace returns the face for key, building its font chain on first use.
+func GetFace(key FaceKey) *Face {
+	if face := faces[key]; face != nil {
+		return face
+	}
+	if len(fontSources) == 0 {
+		// no font loaded, only what was put into Characters by hand
+		return &Face{Key: key, Metrics: FontMetrics, glyphs: Characters, missing: make(map[rune]bool)}
+	}
+
+	face := &Face{
+		Key:     key,
+		glyphs:  make(map[rune]*Character),
+		missing: make(map[rune]bool),
+	}
+
+	family := familyFor(key.Family)
+
+	// the family's own font in that style, or its regular font made bold/oblique
+	for _, styled := range []bool{true, false} {
+		for _, src := range fontSources {
+			if src.family != family || (styled && (src.bold != key.Bold || src.italic != key.Italic)) ||
+				(!styled && (src.bold || src.italic)) || !src.load() {
+				continue
+			}
+			face.chain = append(face.chain, faceFont{src: src, synthBold: key.Bold && !src.bold, synthOblique: key.Italic && !src.italic})
+			break
+		}
+		if len(face.chain) > 0 {
+			break
+		}
+	}
+
+	for _, src := range fontSources {
+		face.chain = append(face.chain, faceFont{src: src, synthBold: key.Bold && !src.bold, synthOblique: key.Italic && !src.italic})
+	}
+
+	for _, f := range face.chain {
+		if f.src.load() {
+			face.Metrics = f.src.face.Metrics()
+			break
+		}
+	}
+
+	faces[key] = face
+	return face
+}
+
+// familyFor maps a generic family to the font family name of the files.
+func familyFor(family string) string {
+	if family == FamilyMonospace {
+		for _, src := range fontSources {
+			if strings.Contains(src.family, "mono") && !src.bold && !src.italic {
+				return src.family
+			}
+		}
+	}
+
+	if len(fontSources) == 0 {
+		return ""
+	}
+	return fontSources[0].family
+}
+
+func regularFace() *Face {
+	return GetFace(FaceKey{})
+}
+
+func (f *Face) LineHeight(scale float32) float32 {
+	return float32(f.Metrics.Height>>6) * scale
+}
+
+func (f *Face) Ascent(scale float32) float32 {
+	return float32(f.Metrics.Ascent>>6) * scale
+}
+
+func (f *Face) Descent(scale float32) float32 {
+	return float32(f.Metrics.Descent>>6) * scale
+}
+
+// glyph returns the character for ch, rasterizing it from the first font
+// of the chain that has it. nil when no font covers the rune.
+func (f *Face) glyph(ch rune) *Character {
+	if char := f.glyphs[ch]; char != nil {
+		return char
+	}
+	if f.missing[ch] {
+		return nil
+	}
+
+	for _, ff := range f.chain {
+		if !ff.src.load() || ff.src.font.Index(ch) == 0 {
+			continue
+		}
+		char, err := rasterize(ff, ch)
+		if err != nil {
+			fmt.Printf("Char img ? %c: %v\n", ch, err)
+			break
+		}
+		f.glyphs[ch] = char
+		return char
+	}
+
+	f.missing[ch] = true
+	return nil
+}
+
+// resolve maps ch to the rune actually drawn for it, the fallback glyph
+// when no font of the chain covers it.
+func (f *Face) resolve(ch rune) (rune, *Character) {
+	if char := f.glyph(ch); char != nil {
+		return ch, char
+	}
+	return fallbackRune, f.glyph(fallbackRune)
+}
+
+func rasterize(ff faceFont, ch rune) (*Character, error) {
+	face := ff.src.face
+
+	bounds, advance, ok := face.GlyphBounds(ch)
+	if !ok {
+		return nil, fmt.Errorf("glyph ? %c", ch)
+	}
+
+	glyphWidth := int(bounds.Max.X-bounds.Min.X) >> 6
+	glyphHeight := int(bounds.Max.Y-bounds.Min.Y) >> 6
+
+	bearingX := int(bounds.Min.X >> 6)
+	bearingY := int(bounds.Max.Y >> 6)
+
+	if glyphWidth <= 0 {
+		glyphWidth = 1
+	}
+	if glyphHeight <= 0 {
+		glyphHeight = 1
+	}
+
+	img := image.NewAlpha(image.Rect(0, 0, glyphWidth, glyphHeight))
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.Opaque,
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: -bounds.Min.X,
+			Y: -bounds.Min.Y,
+		},
+	}
+
+	drawer.DrawString(string(ch))
+
+	advanceWidth := fixedToFloat(advance)
+
+	if ff.synthOblique {
+		var shift int
+		img, shift = shear(img, glyphHeight-bearingY)
+		bearingX += shift
+	}
+	if ff.synthBold {
+		img = embolden(img)
+		advanceWidth++
+	}
+
+	page, uv, err := packGlyph(img)
+	if err != nil {
+		return nil, fmt.Errorf("atlas ? %c: %v", ch, err)
+	}
+
+	return &Character{
+		Page:    page,
+		UV:      uv,
+		Size:    [2]int32{int32(img.Rect.Dx()), int32(img.Rect.Dy())},
+		Bearing: [2]int32{int32(bearingX), int32(bearingY)},
+		Advance: int32(math.Round(float64(advanceWidth))),
+		face:    face,
+		advance: advanceWidth,
+	}, nil
+}
+
+// embolden smears the coverage one pixel to the right.
+func embolden(src *image.Alpha) *image.Alpha {
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewAlpha(image.Rect(0, 0, width+1, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x <= width; x++ {
+			var a, b uint8
+			if x < width {
+				a = src.Pix[y*src.Stride+x]
+			}
+			if x > 0 {
+				b = src.Pix[y*src.Stride+x-1]
+			}
+			dst.Pix[y*dst.Stride+x] = max(a, b)
+		}
+	}
+
+	return dst
+}
+
+// shear slants the glyph around its baseline row. It returns the new image
+// and how far the left edge moved, to be added to the bearing.
+func shear(src *image.Alpha, baseline int) (*image.Alpha, int) {
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+
+	offset := func(y int) int {
+		return int(math.Round(float64(baseline-y) * obliqueSlant))
+	}
+	minShift, maxShift := min(offset(0), offset(height-1)), max(offset(0), offset(height-1))
+
+	dst := image.NewAlpha(image.Rect(0, 0, width+maxShift-minShift, height))
+	for y := 0; y < height; y++ {
+		shift := offset(y) - minShift
+		copy(dst.Pix[y*dst.Stride+shift:], src.Pix[y*src.Stride:y*src.Stride+width])
+	}
+
+	return dst, minShift
+}
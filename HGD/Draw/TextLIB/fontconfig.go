@@ -0,0 +1,112 @@
+package TextLIB
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fontconfigDirs collects the <dir> entries of a fontconfig file, following
+// its <include>s the way fc-cache does.
+func fontconfigDirs(path string) []string {
+	var dirs []string
+	readFontconfig(path, 0, &dirs)
+	return dirs
+}
+
+func readFontconfig(path string, depth int, dirs *[]string) {
+	if depth > 8 {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if info.IsDir() {
+		matches, _ := filepath.Glob(filepath.Join(path, "*.conf"))
+		sort.Strings(matches)
+		for _, match := range matches {
+			readFontconfig(match, depth+1, dirs)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || (start.Name.Local != "dir" && start.Name.Local != "include") {
+			continue
+		}
+
+		var value string
+		if err := decoder.DecodeElement(&value, &start); err != nil {
+			continue
+		}
+
+		target := expandFontconfigPath(strings.TrimSpace(value), attr(start, "prefix"), filepath.Dir(path))
+		if target == "" {
+			continue
+		}
+
+		if start.Name.Local == "dir" {
+			*dirs = append(*dirs, target)
+		} else {
+			readFontconfig(target, depth+1, dirs)
+		}
+	}
+}
+
+func expandFontconfigPath(value, prefix, base string) string {
+	if value == "" {
+		return ""
+	}
+
+	home, _ := os.UserHomeDir()
+
+	switch {
+	case prefix == "xdg":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			if home == "" {
+				return ""
+			}
+			dataHome = filepath.Join(home, ".local/share")
+		}
+		return filepath.Join(dataHome, value)
+	case strings.HasPrefix(value, "~"):
+		if home == "" {
+			return ""
+		}
+		return filepath.Join(home, value[1:])
+	case !filepath.IsAbs(value):
+		return filepath.Join(base, value)
+	}
+
+	return value
+}
+
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package TextLIB
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/RDLxxx/Himera/HGD/utils"
+)
+
+// PrimaryFont is the bundled font used for metrics and for every rune it covers.
+const PrimaryFont = "Hasklig.ttf"
+
+// UserFonts are searched right after the bundled fonts. HIMERA_FONTS, a
+// list of font files separated like PATH, is appended at startup.
+var UserFonts []string
+
+// SystemFonts turns scanning of the system font directories on or off.
+var SystemFonts = true
+
+var bundledFontDir = findBundledFontDir()
+
+// findBundledFontDir looks for the ttf directory next to the executable,
+// where build.py copies it, then in the source tree it was built from and
+// under the working directory.
+func findBundledFontDir() string {
+	var dirs []string
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		dirs = append(dirs, filepath.Join(filepath.Dir(exe), "HGD", "ttf"), filepath.Join(filepath.Dir(exe), "ttf"))
+	}
+	source := filepath.Join(utils.GetExecPath(), "../../ttf")
+	dirs = append(dirs, source, filepath.Join("HGD", "ttf"))
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, PrimaryFont)); err == nil {
+			return dir
+		}
+	}
+	return source
+}
+
+func init() {
+	if env := os.Getenv("HIMERA_FONTS"); env != "" {
+		UserFonts = append(UserFonts, filepath.SplitList(env)...)
+	}
+}
+
+// FontChain returns the ordered list of font files: bundled fonts (the
+// primary one first), user-configured fonts and then system fonts.
+func FontChain() []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(paths ...string) {
+		for _, path := range paths {
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			if !seen[path] {
+				seen[path] = true
+				chain = append(chain, path)
+			}
+		}
+	}
+
+	add(filepath.Join(bundledFontDir, PrimaryFont))
+	add(scanFontDir(bundledFontDir)...)
+	add(UserFonts...)
+
+	if SystemFonts {
+		for _, dir := range systemFontDirs() {
+			add(scanFontDir(dir)...)
+		}
+	}
+
+	return chain
+}
+
+func systemFontDirs() []string {
+	home, _ := os.UserHomeDir()
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" && home != "" {
+		dataHome = filepath.Join(home, ".local/share")
+	}
+
+	var dirs []string
+	if dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "fonts"))
+	}
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".fonts"))
+	}
+	dirs = append(dirs, fontconfigDirs("/etc/fonts/fonts.conf")...)
+	dirs = append(dirs, "/usr/local/share/fonts", "/usr/share/fonts")
+
+	return dirs
+}
+
+// scanFontDir lists the TrueType fonts under dir, regular faces of
+// general-purpose families first so they win over decorative ones.
+func scanFontDir(dir string) []string {
+	var fonts []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && isFontFile(path) {
+			fonts = append(fonts, path)
+		}
+		return nil
+	})
+
+	sort.SliceStable(fonts, func(i, j int) bool {
+		return fontRank(fonts[i]) < fontRank(fonts[j])
+	})
+
+	return fonts
+}
+
+func isFontFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ttf", ".otf":
+		return true
+	}
+	return false
+}
+
+var (
+	preferredFamilies = []string{"dejavusans", "notosans", "liberationsans", "freesans", "droidsans"}
+	styledNames       = []string{"bold", "italic", "oblique", "light", "thin", "black", "condensed", "mono"}
+)
+
+func fontRank(path string) int {
+	name := strings.ToLower(filepath.Base(path))
+
+	rank := len(preferredFamilies)
+	for i, family := range preferredFamilies {
+		if strings.HasPrefix(name, family) {
+			rank = i
+			break
+		}
+	}
+
+	for _, styled := range styledNames {
+		if strings.Contains(name, styled) {
+			return rank + len(preferredFamilies) + 1
+		}
+	}
+
+	return rank
+}
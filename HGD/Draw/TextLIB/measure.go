@@ -0,0 +1,75 @@
+package TextLIB
+
+import "golang.org/x/image/math/fixed"
+
+const (
+	fallbackRune    = 0x00D8
+	measureCacheMax = 1 << 15
+)
+
+type measureKey struct {
+	face  *Face
+	text  string
+	scale float32
+}
+
+var measureCache = make(map[measureKey]float32)
+
+// MeasureText returns the advance width of text at scale in the regular face.
+func MeasureText(text string, scale float32) float32 {
+	return MeasureTextFace(regularFace(), text, scale)
+}
+
+// MeasureTextFace returns the advance width of text at scale, using the glyph
+// advances and kerning of the face. Results are cached per text and scale.
+func MeasureTextFace(face *Face, text string, scale float32) float32 {
+	key := measureKey{face, text, scale}
+	if width, ok := measureCache[key]; ok {
+		return width
+	}
+
+	width := float32(0)
+	prev := rune(-1)
+	for _, ch := range text {
+		ch, char := face.resolve(ch)
+		if char == nil {
+			continue
+		}
+		width += face.kern(prev, ch) + char.advanceWidth()
+		prev = ch
+	}
+	width *= scale
+
+	if len(measureCache) >= measureCacheMax {
+		clear(measureCache)
+	}
+	measureCache[key] = width
+
+	return width
+}
+
+func (c *Character) advanceWidth() float32 {
+	if c.face == nil {
+		return float32(c.Advance)
+	}
+	return c.advance
+}
+
+// kern is the unscaled kerning between two resolved runes, zero at the start
+// of a run (prev -1) or when the glyphs come from different fonts.
+func (f *Face) kern(prev, ch rune) float32 {
+	if prev < 0 {
+		return 0
+	}
+
+	a, b := f.glyphs[prev], f.glyphs[ch]
+	if a == nil || b == nil || a.face == nil || a.face != b.face {
+		return 0
+	}
+
+	return fixedToFloat(b.face.Kern(prev, ch))
+}
+
+func fixedToFloat(v fixed.Int26_6) float32 {
+	return float32(v) / 64
+}
@@ -1,7 +1,6 @@
 package TextLIB
 
 import (
-	"github.com/go-gl/gl/v4.1-core/gl"
 	"golang.org/x/image/font"
 )
 
@@ -13,74 +12,48 @@ const (
 )
 
 type Character struct {
-	TextureID uint32
-	Size      [2]int32
-	Bearing   [2]int32
-	Advance   int32
+	Page    int
+	UV      [4]float32
+	Size    [2]int32
+	Bearing [2]int32
+	Advance int32
+
+	face    font.Face
+	advance float32
 }
 
+// Characters is the glyph set of the regular face.
 var Characters map[rune]*Character
-var fontFace font.Face
 
+// DrawText queues text with its baseline at y. Nothing reaches the screen
+// until Flush.
 func DrawText(program uint32, text string, x, y float32, scale float32, color [3]float32) {
-	gl.UseProgram(program)
-	gl.ActiveTexture(gl.TEXTURE0)
-
-	var vao, vbo uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.GenBuffers(1, &vbo)
-	gl.BindVertexArray(vao)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, 24*4, nil, gl.DYNAMIC_DRAW)
-
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointer(0, 4, gl.FLOAT, false, 0, nil)
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-
-	colorLoc := gl.GetUniformLocation(program, gl.Str("textColor\x00"))
-	gl.Uniform3f(colorLoc, color[0], color[1], color[2])
-
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	DrawTextFace(program, regularFace(), text, x, y, scale, color)
+}
 
+func DrawTextFace(program uint32, face *Face, text string, x, y float32, scale float32, color [3]float32) {
 	currentX := x
+	prev := rune(-1)
 
 	for _, ch := range text {
-		char := Characters[ch]
+		ch, char := face.resolve(ch)
 		if char == nil {
-			char = Characters[0x00D8]
-			if char == nil {
-				continue
-			}
+			continue
 		}
 
+		currentX += face.kern(prev, ch) * scale
+		prev = ch
+
 		xpos := currentX + float32(char.Bearing[0])*scale
 		ypos := y - float32(char.Size[1])*scale + float32(char.Bearing[1])*scale
 
 		w := float32(char.Size[0]) * scale
 		h := float32(char.Size[1]) * scale
 
-		vertices := []float32{
-			xpos, ypos + h, 0.0, 1.0,
-			xpos, ypos, 0.0, 0.0,
-			xpos + w, ypos, 1.0, 0.0,
-
-			xpos, ypos + h, 0.0, 1.0,
-			xpos + w, ypos, 1.0, 0.0,
-			xpos + w, ypos + h, 1.0, 1.0,
+		if w > 0 && h > 0 {
+			batch.add(program, char.Page, xpos, ypos, xpos+w, ypos+h, char.UV, color)
 		}
 
-		gl.BindTexture(gl.TEXTURE_2D, char.TextureID)
-		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-		gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(vertices))
-
-		gl.DrawArrays(gl.TRIANGLES, 0, 6)
-
-		currentX += float32(char.Advance) * scale
+		currentX += char.advanceWidth() * scale
 	}
-
-	gl.BindVertexArray(0)
-	gl.BindTexture(gl.TEXTURE_2D, 0)
-	gl.DeleteVertexArrays(1, &vao)
-	gl.DeleteBuffers(1, &vbo)
 }
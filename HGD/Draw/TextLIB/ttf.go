@@ -2,125 +2,131 @@ package TextLIB
 
 import (
 	"fmt"
-	"image"
-	"image/color"
-	"image/draw"
-	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
-	"golang.org/x/image/math/fixed"
 )
 
-func InitFont() error {
-	fontBytes, err := ioutil.ReadFile("HGD/ttf/Hasklig.ttf")
+// fontSource is one font file of the chain. Fonts are parsed the first time
+// a rune is looked up in them.
+type fontSource struct {
+	path   string
+	family string
+	bold   bool
+	italic bool
+
+	font   *truetype.Font
+	face   font.Face
+	failed bool
+}
+
+var fontSources []*fontSource
+
+func newFontSource(path string) *fontSource {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+
+	family, style := name, ""
+	if i := strings.IndexAny(name, "-_"); i >= 0 {
+		family, style = name[:i], name[i+1:]
+	}
+
+	return &fontSource{
+		path:   path,
+		family: family,
+		bold:   strings.Contains(style, "bold") || strings.Contains(style, "black") || strings.Contains(style, "heavy"),
+		italic: strings.Contains(style, "italic") || strings.Contains(style, "oblique"),
+	}
+}
+
+func (src *fontSource) load() bool {
+	if src.font != nil {
+		return true
+	}
+	if src.failed {
+		return false
+	}
+
+	fontBytes, err := os.ReadFile(src.path)
 	if err != nil {
-		return fmt.Errorf("read font ? %v", err)
+		src.failed = true
+		return false
 	}
 
 	f, err := truetype.Parse(fontBytes)
 	if err != nil {
-		return fmt.Errorf("parse font ? %v", err)
+		src.failed = true
+		return false
 	}
 
-	Characters = make(map[rune]*Character)
-
-	fontFace = truetype.NewFace(f, &truetype.Options{
+	src.font = f
+	src.face = truetype.NewFace(f, &truetype.Options{
 		Size:    FontSize,
 		DPI:     Dpi,
 		Hinting: font.HintingFull,
 	})
 
-	FontMetrics = fontFace.Metrics()
+	return true
+}
+
+func InitFont() error {
+	clear(measureCache)
+	clear(faces)
+	resetAtlas()
+
+	fontSources = fontSources[:0]
+	for _, path := range FontChain() {
+		fontSources = append(fontSources, newFontSource(path))
+	}
+
+	// the first font that loads is the primary one and sets the metrics
+	for len(fontSources) > 0 && !fontSources[0].load() {
+		fontSources = fontSources[1:]
+	}
+	if len(fontSources) == 0 {
+		return fmt.Errorf("read font ? no usable font in %s", bundledFontDir)
+	}
+
+	regular := GetFace(FaceKey{})
+	Characters = regular.glyphs
+	FontMetrics = regular.Metrics
 
 	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
 
+	// Warm the atlas with what almost every page needs, anything else is
+	// rasterized the first time it is drawn or measured.
 	ranges := [][2]rune{
 		{32, 126},
 		{160, 255},
-		{1040, 1103},
-		{1025, 1025},
-		{1105, 1105},
+		{1024, 1119},
 	}
 
 	for _, r := range ranges {
 		for ch := r[0]; ch <= r[1]; ch++ {
-			if err := CreateCharacterTexture(fontFace, ch); err != nil {
-				fmt.Printf("Char img ? %c: %v\n", ch, err)
-			}
+			regular.glyph(ch)
 		}
 	}
 
-	if Characters[rune('?')] == nil {
-		CreateCharacterTexture(fontFace, '?')
-	}
-
 	return nil
 }
 
-func CreateCharacterTexture(face font.Face, ch rune) error {
-	bounds, advance, ok := face.GlyphBounds(ch)
-	if !ok {
-		return fmt.Errorf("glyph ? %c", ch)
-	}
-
-	glyphWidth := int(bounds.Max.X-bounds.Min.X) >> 6
-	glyphHeight := int(bounds.Max.Y-bounds.Min.Y) >> 6
-
-	bearingX := int(bounds.Min.X >> 6)
-	bearingY := int(bounds.Max.Y >> 6)
-
-	if glyphWidth <= 0 {
-		glyphWidth = 1
-	}
-	if glyphHeight <= 0 {
-		glyphHeight = 1
-	}
-
-	img := image.NewRGBA(image.Rect(0, 0, glyphWidth, glyphHeight))
-
-	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0, 0, 0, 0}}, image.Point{}, draw.Src)
-
-	drawer := &font.Drawer{
-		Dst:  img,
-		Src:  &image.Uniform{color.RGBA{255, 255, 255, 255}},
-		Face: face,
-		Dot: fixed.Point26_6{
-			X: -bounds.Min.X,
-			Y: -bounds.Min.Y,
-		},
+// AddFallbackFont appends a font to the chain searched for runes the
+// fonts before it do not cover.
+func AddFallbackFont(path string) error {
+	src := newFontSource(path)
+	if !src.load() {
+		return fmt.Errorf("read font ? %s", path)
 	}
 
-	drawer.DrawString(string(ch))
-
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-
-	gl.TexImage2D(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		int32(glyphWidth),
-		int32(glyphHeight),
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(img.Pix),
-	)
-
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	Characters[ch] = &Character{
-		TextureID: texture,
-		Size:      [2]int32{int32(glyphWidth), int32(glyphHeight)},
-		Bearing:   [2]int32{int32(bearingX), int32(bearingY)},
-		Advance:   int32(advance >> 6),
+	fontSources = append(fontSources, src)
+	for _, face := range faces {
+		face.chain = append(face.chain, faceFont{src: src, synthBold: face.Key.Bold, synthOblique: face.Key.Italic})
+		clear(face.missing)
 	}
+	clear(measureCache)
 
 	return nil
 }
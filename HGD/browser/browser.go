@@ -1,7 +1,5 @@
 package browser
 
-import web "github.com/RDLxxx/Himera/HDS/core/web/html"
-
 type RenderState struct {
 	NeedsRedraw   bool
 	LastWidth     int
@@ -11,27 +9,28 @@ type RenderState struct {
 	LastInputText string
 	LastFocused   bool
 	LastCursorPos int
+	LastResources int64
 }
 
 type Browser struct {
+	*Tab
+	Tabs      []*Tab
+	ActiveTab int
+
 	CurrentWidth    int
 	CurrentHeight   int
-	Link            string
 	Ua              string
-	InputText       string
-	CursorPosition  int
+	TabBarHeight    float32
 	InputBoxHeight  float32
 	InputBoxFocused bool
 	BlinkTimer      float32
 	RState          *RenderState
 
-	Zoom         float32
-	ScrollOffset float32
 	IsFullscreen bool
 
-	ContentHeight float32
-
-	HtmlRenderer *web.HTMLRenderer
+	// HoverLink is the target of the link under the mouse, shown in the
+	// status area.
+	HoverLink string
 
 	WindowedX, WindowedY, WindowedWidth, WindowedHeight int
 	WasMaximizedBeforeFullscreen                        bool
@@ -39,23 +38,27 @@ type Browser struct {
 }
 
 func NewBrowser(Width int, Height int, WelcomeLink string, SUa string, IBoxHeight float32) *Browser {
+	tab := NewTab(WelcomeLink)
+
 	return &Browser{
+		Tab:            tab,
+		Tabs:           []*Tab{tab},
 		CurrentWidth:   Width,
 		CurrentHeight:  Height,
-		Link:           WelcomeLink,
 		Ua:             SUa,
+		TabBarHeight:   32.0,
 		InputBoxHeight: IBoxHeight,
 
 		// Initially const, then mut!!!
 		InputBoxFocused: false,
 		BlinkTimer:      0.0,
 		RState:          &RenderState{NeedsRedraw: true},
-		InputText:       WelcomeLink,
-		CursorPosition:  len(WelcomeLink),
 		IsMaximized:     false,
-		Zoom:            1.0,
-		ScrollOffset:    0.0,
-		ContentHeight:   0.0,
 		IsFullscreen:    false,
 	}
 }
+
+// ChromeHeight is the height of the tab strip and URL box above the page.
+func (b *Browser) ChromeHeight() float32 {
+	return b.TabBarHeight + b.InputBoxHeight
+}
@@ -0,0 +1,52 @@
+package browser
+
+import web "github.com/RDLxxx/Himera/HDS/core/web/html"
+
+// HistoryEntry is one visited page. Page keeps the response body so going
+// back does not fetch it again, it is empty when the load failed. Form is
+// the POST submission that led to the page, sent again if it is refetched.
+type HistoryEntry struct {
+	URL          string
+	Title        string
+	ScrollOffset float32
+	Zoom         float32
+	Page         string
+	Form         *web.FormSubmission
+}
+
+type History struct {
+	Entries []HistoryEntry
+	Index   int
+}
+
+func NewHistory() *History {
+	return &History{Index: -1}
+}
+
+// Push adds entry after the current one, dropping the forward entries.
+func (h *History) Push(entry HistoryEntry) {
+	h.Entries = append(h.Entries[:h.Index+1], entry)
+	h.Index = len(h.Entries) - 1
+}
+
+func (h *History) Current() *HistoryEntry {
+	if h.Index < 0 || h.Index >= len(h.Entries) {
+		return nil
+	}
+	return &h.Entries[h.Index]
+}
+
+func (h *History) CanGo(delta int) bool {
+	index := h.Index + delta
+	return index >= 0 && index < len(h.Entries)
+}
+
+// Go moves delta entries back (negative) or forward and returns the new
+// current entry.
+func (h *History) Go(delta int) *HistoryEntry {
+	if !h.CanGo(delta) {
+		return nil
+	}
+	h.Index += delta
+	return h.Current()
+}
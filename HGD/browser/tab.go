@@ -0,0 +1,103 @@
+package browser
+
+import (
+	"context"
+
+	web "github.com/RDLxxx/Himera/HDS/core/web/html"
+)
+
+// Tab is the state of one page. Browser embeds the active tab, so
+// core.Browse.Link and friends always refer to it.
+type Tab struct {
+	Link           string
+	InputText      string
+	CursorPosition int
+
+	Zoom          float32
+	ScrollOffset  float32
+	ContentHeight float32
+
+	HtmlRenderer *web.HTMLRenderer
+	History      *History
+
+	// Loading is set while a page is fetched in the background. LoadID
+	// tells the current load apart from stopped ones still finishing.
+	Loading    bool
+	LoadID     int
+	CancelLoad context.CancelFunc
+
+	// Status is why the page failed to load, an HTTP status line such as
+	// "404 Not Found" or a failure like "DNS error". Empty when it loaded.
+	Status string
+}
+
+func NewTab(link string) *Tab {
+	return &Tab{
+		Link:           link,
+		InputText:      link,
+		CursorPosition: len(link),
+		Zoom:           1.0,
+		History:        NewHistory(),
+	}
+}
+
+// Title is what the tab strip shows for the tab.
+func (t *Tab) Title() string {
+	if t.HtmlRenderer != nil {
+		if title := t.HtmlRenderer.Title(); title != "" {
+			return title
+		}
+	}
+	if t.Link != "" {
+		return t.Link
+	}
+	return "New Tab"
+}
+
+// StopLoading cancels the page load in progress, its result is dropped.
+func (t *Tab) StopLoading() {
+	if t.CancelLoad != nil {
+		t.CancelLoad()
+		t.CancelLoad = nil
+	}
+	t.Loading = false
+	t.LoadID++
+}
+
+// OpenTab adds a blank tab after the others and returns its index.
+func (b *Browser) OpenTab() int {
+	b.Tabs = append(b.Tabs, NewTab(""))
+	return len(b.Tabs) - 1
+}
+
+func (b *Browser) SelectTab(index int) {
+	if index < 0 || index >= len(b.Tabs) {
+		return
+	}
+	b.ActiveTab = index
+	b.Tab = b.Tabs[index]
+}
+
+// CloseTab removes a tab and releases its page. The last tab is kept, it
+// reports false instead so the caller can close the window.
+func (b *Browser) CloseTab(index int) bool {
+	if index < 0 || index >= len(b.Tabs) {
+		return true
+	}
+	if len(b.Tabs) == 1 {
+		return false
+	}
+
+	b.Tabs[index].StopLoading()
+	if renderer := b.Tabs[index].HtmlRenderer; renderer != nil {
+		renderer.Release()
+	}
+	b.Tabs = append(b.Tabs[:index], b.Tabs[index+1:]...)
+
+	active := b.ActiveTab
+	if index < active || active >= len(b.Tabs) {
+		active--
+	}
+	b.SelectTab(max(active, 0))
+	return true
+}
@@ -0,0 +1,7 @@
+#version 410
+in vec2 TexCoords;
+out vec4 FragColor;
+uniform sampler2D image;
+void main() {
+    FragColor = texture(image, TexCoords);
+}
\ No newline at end of file
@@ -1,9 +1,9 @@
 #version 410
 in vec2 TexCoords;
+in vec3 TextColor;
 out vec4 color;
 uniform sampler2D text;
-uniform vec3 textColor;
 void main() {
     vec4 sampled = vec4(1.0, 1.0, 1.0, texture(text, TexCoords).r);
-    color = vec4(textColor, 1.0) * sampled;
+    color = vec4(TextColor, 1.0) * sampled;
 }
\ No newline at end of file
@@ -1,6 +1,8 @@
 #version 410
 layout (location = 0) in vec4 vertex;
+layout (location = 1) in vec3 color;
 out vec2 TexCoords;
+out vec3 TextColor;
 
 uniform mat4 projection;
 
@@ -8,4 +10,5 @@ void main()
 {
     gl_Position = projection * vec4(vertex.xy, 0.0, 1.0);
     TexCoords = vertex.zw;
-}  
\ No newline at end of file
+    TextColor = color;
+}  
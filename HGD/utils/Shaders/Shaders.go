@@ -8,3 +8,4 @@ import (
 
 var TextShaders = ReadShaders(filepath.Join(utils.GetExecPath(), "../../../HGD/shaders/text"), "VertexText.glsl", "FragText.frag")
 var RectShaders = ReadShaders(filepath.Join(utils.GetExecPath(), "../../../HGD/shaders/figures/rect"), "VertexRect.glsl", "FragRect.frag")
+var ImageShaders = ReadShaders(filepath.Join(utils.GetExecPath(), "../../../HGD/shaders/figures/image"), "VertexImage.glsl", "FragImage.frag")
@@ -9,3 +9,5 @@ require (
 	golang.org/x/image v0.29.0
 	golang.org/x/net v0.42.0
 )
+
+require golang.org/x/text v0.27.0 // indirect
@@ -42,6 +42,7 @@ func main() {
 	}
 
 	defer drawer.CleanupGLResources()
+	defer TextLIB.CleanupGLResources()
 
 	window.MakeContextCurrent()
 	window.SetMaximizeCallback(himera.WindowMaximizeCallback)
@@ -49,6 +50,7 @@ func main() {
 	window.SetKeyCallback(himera.KeyCallback)
 	window.SetCharCallback(himera.CharCallback)
 	window.SetMouseButtonCallback(himera.MouseButtonCallback)
+	window.SetCursorPosCallback(himera.CursorPosCallback)
 	window.SetScrollCallback(himera.ScrollCallback)
 
 	himera.InitializeWindowState(window)
@@ -85,14 +87,17 @@ func main() {
 	gl.ClearColor(0.1, 0.1, 0.1, 1.0)
 
 	himera.UpdateProjection(ProgramShaders.TextShaderProgram)
-	himera.UpdateContent(core.Browse.Link, core.Browse.Ua)
-
-	himera.UpdateScrollLimits()
+	himera.Navigate(core.Browse.Link)
 
 	glfw.SwapInterval(1)
 
+	ibeamCursor := glfw.CreateStandardCursor(glfw.IBeamCursor)
+	arrowCursor := glfw.CreateStandardCursor(glfw.ArrowCursor)
+	handCursor := glfw.CreateStandardCursor(glfw.HandCursor)
+
 	for !window.ShouldClose() {
 		glfw.WaitEventsTimeout(0.016)
+		himera.ProcessLoads()
 
 		if himera.CheckNeedsRedraw() {
 			if core.Browse.RState.LastWidth != core.Browse.CurrentWidth ||
@@ -101,15 +106,21 @@ func main() {
 				himera.UpdateProjection(ProgramShaders.TextShaderProgram)
 			}
 
-			if core.Browse.InputBoxFocused {
-				window.SetCursor(glfw.CreateStandardCursor(glfw.IBeamCursor))
-			} else {
-				window.SetCursor(glfw.CreateStandardCursor(glfw.ArrowCursor))
+			switch {
+			case core.Browse.InputBoxFocused:
+				window.SetCursor(ibeamCursor)
+			case core.Browse.HoverLink != "":
+				window.SetCursor(handCursor)
+			default:
+				window.SetCursor(arrowCursor)
 			}
 
 			gl.Clear(gl.COLOR_BUFFER_BIT)
-			himera.RenderHTML(ProgramShaders.TextShaderProgram)
+			himera.RenderHTML(ProgramShaders.TextShaderProgram, ProgramShaders.RectShaderProgram, ProgramShaders.ImageShaderProgram)
+			himera.DrawTabStrip(ProgramShaders.RectShaderProgram, ProgramShaders.TextShaderProgram)
 			himera.DrawURLBox(ProgramShaders.RectShaderProgram, ProgramShaders.TextShaderProgram)
+			himera.DrawStatus(ProgramShaders.RectShaderProgram, ProgramShaders.TextShaderProgram)
+			TextLIB.Flush()
 			window.SwapBuffers()
 		}
 	}